@@ -2,12 +2,17 @@ package api
 
 import (
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"backup-server-agent/internal/backup"
 	"backup-server-agent/internal/config"
 	"backup-server-agent/internal/cron"
+	"backup-server-agent/internal/execution"
 	"backup-server-agent/internal/logger"
 	"backup-server-agent/internal/monitor"
+	"backup-server-agent/internal/notify"
 	"github.com/gin-gonic/gin"
 )
 
@@ -41,6 +46,14 @@ func NewRouter(cfg *config.Config, log *logger.Logger, cronMgr *cron.CronManager
 		api.POST("/task/config", router.setTaskConfig)
 		api.POST("/task/execute", router.executeTask)
 		api.GET("/backups", router.getBackups)
+		api.POST("/notify/test", router.testNotification)
+		api.GET("/task/:id/records", router.getTaskRecords)
+		api.GET("/records/:rid", router.getRecord)
+		api.GET("/records/:rid/log", router.getRecordLog)
+		api.POST("/task/:id/run", router.runTaskOnce)
+		api.POST("/task/:id/pause", router.pauseTask)
+		api.POST("/task/:id/resume", router.resumeTask)
+		api.GET("/cron/entries", router.getCronEntries)
 	}
 
 	return r
@@ -144,6 +157,7 @@ func (r *Router) setTaskConfig(c *gin.Context) {
 func (r *Router) executeTask(c *gin.Context) {
 	var req struct {
 		TaskID          int    `json:"task_id"`
+		Type            string `json:"type"`
 		SourcePath      string `json:"source_path"`
 		CreateArchive   bool   `json:"create_archive"`
 		ArchiveFormat   string `json:"archive_format"`
@@ -156,6 +170,19 @@ func (r *Router) executeTask(c *gin.Context) {
 		CleanupDays     int    `json:"cleanup_days"`
 		IsDockerCompose bool   `json:"is_docker_compose"`
 		DockerComposePath string `json:"docker_compose_path"`
+		ShellCommand     string            `json:"shell_command"`
+		ScriptContainer  string            `json:"script_container"`
+		CurlURL          string            `json:"curl_url"`
+		CurlMethod       string            `json:"curl_method"`
+		CurlHeaders      map[string]string `json:"curl_headers"`
+		CurlTimeout      string            `json:"curl_timeout"`
+		CurlExpectStatus []int             `json:"curl_expect_status"`
+		DBDriver         string            `json:"db_driver"`
+		DBHost           string            `json:"db_host"`
+		DBPort           int               `json:"db_port"`
+		DBName           string            `json:"db_name"`
+		DBUser           string            `json:"db_user"`
+		DBPassword       string            `json:"db_password"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -166,6 +193,7 @@ func (r *Router) executeTask(c *gin.Context) {
 	// Создаем задачу из запроса
 	task := config.Task{
 		TaskID:           req.TaskID,
+		Type:             req.Type,
 		SourcePath:       req.SourcePath,
 		CreateArchive:    req.CreateArchive,
 		ArchiveFormat:    req.ArchiveFormat,
@@ -178,6 +206,19 @@ func (r *Router) executeTask(c *gin.Context) {
 		CleanupDays:      req.CleanupDays,
 		IsDockerCompose:  req.IsDockerCompose,
 		DockerComposePath: req.DockerComposePath,
+		ShellCommand:     req.ShellCommand,
+		ScriptContainer:  req.ScriptContainer,
+		CurlURL:          req.CurlURL,
+		CurlMethod:       req.CurlMethod,
+		CurlHeaders:      req.CurlHeaders,
+		CurlTimeout:      req.CurlTimeout,
+		CurlExpectStatus: req.CurlExpectStatus,
+		DBDriver:         req.DBDriver,
+		DBHost:           req.DBHost,
+		DBPort:           req.DBPort,
+		DBName:           req.DBName,
+		DBUser:           req.DBUser,
+		DBPassword:       req.DBPassword,
 	}
 
 	// Получаем IP сервера из конфига
@@ -187,7 +228,7 @@ func (r *Router) executeTask(c *gin.Context) {
 	}
 
 	// Выполняем бэкап
-	result, err := backup.ExecuteBackup(task, serverIP, r.logger)
+	result, message, err := backup.ExecuteBackup(task, serverIP, r.logger, r.config)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -198,12 +239,53 @@ func (r *Router) executeTask(c *gin.Context) {
 
 		c.JSON(http.StatusOK, gin.H{
 			"success":      result.Success,
+			"skipped":      result.Skipped,
 			"archive_size": result.ArchiveSize,
 			"files_count":  result.FilesCount,
 			"s3_path":      result.S3Path,
+			"notification": message,
 		})
 }
 
+// testNotification рендерит и отправляет уведомление по настройкам задачи
+// (или глобальным, если task_id не указан или не найден), не выполняя
+// реального бэкапа — для проверки шаблонов и sink'ов.
+func (r *Router) testNotification(c *gin.Context) {
+	var req struct {
+		TaskID  int  `json:"task_id"`
+		Success bool `json:"success"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task := config.Task{}
+	if t := r.config.GetTask(req.TaskID); t != nil {
+		task = *t
+	}
+
+	now := time.Now()
+	notifyCtx := notify.Context{
+		Task:      task,
+		Success:   req.Success,
+		StartTime: now.Add(-time.Minute),
+		EndTime:   now,
+		Duration:  time.Minute,
+	}
+	if !req.Success {
+		notifyCtx.Error = "dry-run test notification"
+	}
+
+	message, err := notify.Send(*r.config, notifyCtx, r.logger)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
 func (r *Router) getBackups(c *gin.Context) {
 	records, err := backup.GetBackupRecords()
 	if err != nil {
@@ -227,9 +309,142 @@ func (r *Router) getBackups(c *gin.Context) {
 		if record.S3Path != "" {
 			backupMap["s3_path"] = record.S3Path
 		}
+		if len(record.HookResults) > 0 {
+			backupMap["hook_results"] = record.HookResults
+		}
 		backups[i] = backupMap
 	}
 
 	c.JSON(http.StatusOK, gin.H{"backups": backups})
 }
 
+// runTaskOnce триггерит немедленное асинхронное выполнение уже
+// сконфигурированной задачи (backup.RunOnce) и сразу отдаёт ID записи
+// internal/execution для опроса через GET /api/records/:rid — не дожидаясь
+// завершения самого бэкапа.
+func (r *Router) runTaskOnce(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	task := r.config.GetTask(taskID)
+	if task == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+
+	serverIP := r.config.ServerIP
+	if serverIP == "" {
+		serverIP = "unknown"
+	}
+
+	recordID, err := backup.RunOnce(*task, serverIP, r.logger, r.config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"record_id": recordID})
+}
+
+// pauseTask снимает задачу с расписания (cron.CronManager.Pause), сохраняя
+// её в конфиге с Paused=true — ручной запуск через /api/task/:id/run
+// по-прежнему доступен.
+func (r *Router) pauseTask(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	if !r.config.SetTaskPaused(taskID, true) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	if err := config.Save(r.config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	r.cronManager.Pause(taskID)
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// resumeTask возвращает ранее приостановленную задачу в расписание
+// (cron.CronManager.Resume).
+func (r *Router) resumeTask(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	if !r.config.SetTaskPaused(taskID, false) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	if err := config.Save(r.config); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	r.cronManager.Resume(taskID)
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// getCronEntries перечисляет все задачи с расписанием вместе с их
+// next/prev временем срабатывания (cron.CronManager.Entries()).
+func (r *Router) getCronEntries(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"entries": r.cronManager.Entries()})
+}
+
+// getTaskRecords отдаёт записи о выполнении задачи (internal/execution),
+// от новых к старым.
+func (r *Router) getTaskRecords(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	records, err := execution.GetRecordsByTask(taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"records": records})
+}
+
+// getRecord отдаёт одну запись о выполнении по её ID.
+func (r *Router) getRecord(c *gin.Context) {
+	record, err := execution.GetRecord(c.Param("rid"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "record not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// getRecordLog стримит файл лога записи о выполнении.
+func (r *Router) getRecordLog(c *gin.Context) {
+	record, err := execution.GetRecord(c.Param("rid"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if record == nil || record.LogPath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "record not found"})
+		return
+	}
+
+	c.FileAttachment(record.LogPath, filepath.Base(record.LogPath))
+}
+
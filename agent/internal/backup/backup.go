@@ -1,25 +1,28 @@
 package backup
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"backup-server-agent/internal/backup/retention"
 	"backup-server-agent/internal/config"
+	"backup-server-agent/internal/execution"
+	"backup-server-agent/internal/hooks"
+	"backup-server-agent/internal/lock"
 	"backup-server-agent/internal/logger"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"backup-server-agent/internal/monitor"
+	"backup-server-agent/internal/notify"
+	"backup-server-agent/internal/storage"
+	"github.com/google/uuid"
 )
 
 type BackupResult struct {
 	Success      bool
+	Skipped      bool // true, если запуск пропущен из-за уже идущего выполнения (SkipIfRunning или истёк MaxWait)
 	ArchivePath  string
 	ArchiveSize  int64
 	FilesCount   int
@@ -27,70 +30,233 @@ type BackupResult struct {
 	S3Path       string
 }
 
-func ExecuteBackup(task config.Task, serverIP string, log *logger.Logger) (*BackupResult, error) {
+// ExecuteBackup выполняет задачу бэкапа и возвращает результат вместе с
+// отрендеренным текстом уведомления (см. internal/notify), чтобы вызывающий
+// код мог сам решить, где его показать.
+func ExecuteBackup(task config.Task, serverIP string, log *logger.Logger, cfg *config.Config) (*BackupResult, string, error) {
+	// Открываем запись о выполнении (internal/execution) — отдельный файл
+	// лога на запуск, на который дальше дублируется весь вывод логгера.
+	run, err := execution.Begin(task.TaskID)
+	if err != nil {
+		log.Warnf("Failed to open execution record: %v", err)
+		run = nil
+	}
+	return executeBackup(task, serverIP, log, cfg, run)
+}
+
+// RunOnce запускает ExecuteBackup в фоне и сразу возвращает ID записи
+// internal/execution, не дожидаясь завершения самого бэкапа — для ручного
+// триггера POST /api/task/:id/run, после которого прогресс опрашивается
+// через GET /api/records/:rid.
+func RunOnce(task config.Task, serverIP string, log *logger.Logger, cfg *config.Config) (string, error) {
+	run, err := execution.Begin(task.TaskID)
+	if err != nil {
+		return "", err
+	}
+	go executeBackup(task, serverIP, log, cfg, run)
+	return run.Record.ID, nil
+}
+
+func executeBackup(task config.Task, serverIP string, log *logger.Logger, cfg *config.Config, run *execution.Run) (*BackupResult, string, error) {
+	startTime := time.Now()
 	result := &BackupResult{}
+	var storageBackend string
+
+	backupID := uuid.NewString()
 
-	// Если это Docker Compose проект, останавливаем его
-	var dockerComposeDir string
-	if task.IsDockerCompose && task.DockerComposePath != "" {
-		log.Infof("Stopping Docker Compose project: %s", task.DockerComposePath)
-		dockerComposeDir = filepath.Dir(task.DockerComposePath)
-		
-		// Пробуем docker compose (новая версия)
-		cmd := exec.Command("docker", "compose", "-f", task.DockerComposePath, "down")
-		cmd.Dir = dockerComposeDir
-		if err := cmd.Run(); err != nil {
-			// Пробуем docker-compose (старая версия)
-			cmd := exec.Command("docker-compose", "-f", task.DockerComposePath, "down")
-			cmd.Dir = dockerComposeDir
-			if err := cmd.Run(); err != nil {
-				log.Warnf("Failed to stop docker-compose: %v", err)
+	if run != nil {
+		log = logger.New(cfg, run.Writer())
+	}
+	log = log.With("task_id", task.TaskID, "source_path", task.SourcePath, "storage_type", task.StorageType, "backup_id", backupID)
+
+	// Блокировка задачи (internal/lock): не даём перекрываться нескольким
+	// запускам одной и той же задачи (cron-тик, системный crontab
+	// --task-id, HTTP /api/task/execute могут сработать одновременно).
+	taskLock, lockErr := lock.TaskLock(task.TaskID)
+	if lockErr != nil {
+		log.Warnf("Failed to prepare task lock: %v", lockErr)
+		taskLock = nil
+	}
+	if taskLock != nil {
+		var maxWait time.Duration
+		if task.MaxWait != "" {
+			if d, err := time.ParseDuration(task.MaxWait); err != nil {
+				log.Warnf("Invalid max_wait %q: %v", task.MaxWait, err)
+			} else {
+				maxWait = d
 			}
 		}
-		
-		defer func() {
-			// Запускаем обратно после завершения
-			log.Infof("Starting Docker Compose project: %s", task.DockerComposePath)
-			cmd := exec.Command("docker", "compose", "-f", task.DockerComposePath, "up", "-d")
-			cmd.Dir = dockerComposeDir
-			if err := cmd.Run(); err != nil {
-				cmd := exec.Command("docker-compose", "-f", task.DockerComposePath, "up", "-d")
-				cmd.Dir = dockerComposeDir
-				if err := cmd.Run(); err != nil {
-					log.Errorf("Failed to start docker-compose: %v", err)
-				}
+		acquired, err := lock.Acquire(taskLock, task.SkipIfRunning, maxWait)
+		if err != nil {
+			log.Warnf("Failed to acquire task lock: %v", err)
+		} else if !acquired {
+			result.Skipped = true
+			result.Error = "skipped: task already running"
+			log.Infof("Skipping task %d: already running", task.TaskID)
+			if run != nil {
+				run.Finish(execution.PolicyFromTask(task), "skipped", result.Error, "", "", 0)
 			}
+			return result, "", nil
+		} else {
+			defer taskLock.Unlock()
+		}
+	}
+
+	// Глобальный лимит одновременных тяжёлых операций (internal/lock):
+	// ждём свободный слот из cfg.MaxConcurrentBackups перед пре-хуками, а не
+	// только перед архивированием/аплоадом — иначе docker_stop в пре-хуках
+	// уже остановил бы контейнеры и держал бы их лежащими всё время
+	// ожидания семафора (MaxConcurrentBackups<=0 — без лимита).
+	if cfg != nil {
+		globalSlot, err := lock.AcquireGlobalSlot(cfg.MaxConcurrentBackups)
+		if err != nil {
+			log.Warnf("Failed to acquire global concurrency slot: %v", err)
+		} else if globalSlot != nil {
+			defer globalSlot.Unlock()
+		}
+	}
+
+	// Запись о выполнении закрывается отложенно, уже после пост-хуков (их
+	// defer регистрируется ниже и выполняется раньше), чтобы их вывод тоже
+	// попал в файл лога запуска.
+	var runStatus, runMsg string
+	if run != nil {
+		defer func() {
+			run.Finish(execution.PolicyFromTask(task), runStatus, runMsg, result.ArchivePath, result.S3Path, result.ArchiveSize)
 		}()
 	}
 
-	// Создаем архив, если нужно
+	_, _, preTotalGB, preFreeGB, err := monitor.GetFilesystemInfo(task.SourcePath)
+	if err != nil {
+		log.Warnf("Failed to read pre-backup disk usage: %v", err)
+	}
+
+	sendNotification := func(execErr error) (*BackupResult, string, error) {
+		notifyCtx := notify.Context{
+			Task:           task,
+			StorageBackend: storageBackend,
+			ArchivePath:    result.ArchivePath,
+			ArchiveSize:    result.ArchiveSize,
+			FilesCount:     result.FilesCount,
+			S3Path:         result.S3Path,
+			Error:          result.Error,
+			Success:        result.Success,
+			StartTime:      startTime,
+			EndTime:        time.Now(),
+			Stats: notify.Stats{
+				PreTotalGB: preTotalGB,
+				PreFreeGB:  preFreeGB,
+			},
+		}
+		notifyCtx.Duration = notifyCtx.EndTime.Sub(notifyCtx.StartTime)
+		if _, _, postTotalGB, postFreeGB, err := monitor.GetFilesystemInfo(task.SourcePath); err == nil {
+			notifyCtx.Stats.PostTotalGB = postTotalGB
+			notifyCtx.Stats.PostFreeGB = postFreeGB
+		} else {
+			log.Warnf("Failed to read post-backup disk usage: %v", err)
+		}
+
+		message := ""
+		if cfg != nil {
+			notifyCtx.PreviousRecords = previousRecords(task.SourcePath, notify.TrendCount(cfg.Notifications))
+			var notifyErr error
+			message, notifyErr = notify.Send(*cfg, notifyCtx, log)
+			if notifyErr != nil {
+				log.Warnf("Failed to send notification: %v", notifyErr)
+			}
+		}
+
+		if run != nil {
+			runStatus = "success"
+			runMsg = fmt.Sprintf("Archive size: %d bytes, files: %d", result.ArchiveSize, result.FilesCount)
+			if execErr != nil || !result.Success {
+				runStatus = "failed"
+				runMsg = result.Error
+			}
+		}
+
+		return result, message, execErr
+	}
+
+	// Имя архива (заполняется ниже, по ходу выполнения задачи) — объявлено
+	// здесь, а не рядом с остальной логикой архивирования, так как на него
+	// уже ссылается defer поста-хуков чуть ниже.
 	var archiveName string
-	if task.CreateArchive {
-		archivePath, name, err := createArchive(task.SourcePath, task.ArchiveFormat, serverIP, log)
+	streamedToStorage := false
+
+	// Пре-хуки (остановка контейнеров, произвольные команды и т.д.)
+	hookState, err := hooks.RunPre(context.Background(), task.PreHooks, log)
+	if err != nil {
+		result.Error = fmt.Sprintf("Pre-hook failed: %v", err)
+		if len(hookState.Results) > 0 {
+			failRecord := BackupRecord{
+				BackupID:    backupID,
+				SourcePath:  task.SourcePath,
+				BackupDate:  time.Now(),
+				Status:      "failed",
+				HookResults: hookState.Results,
+			}
+			if err := SaveBackupRecord(failRecord, log); err != nil {
+				log.Warnf("Failed to save backup record: %v", err)
+			}
+		}
+		return sendNotification(err)
+	}
+	defer func() {
+		if err := hooks.RunPost(context.Background(), task.PostHooks, hookState, log); err != nil {
+			log.Warnf("Post-hook failed: %v", err)
+		}
+		if archiveName != "" && len(hookState.Results) > 0 {
+			if err := UpdateBackupRecordHooks(archiveName, hookState.Results); err != nil {
+				log.Warnf("Failed to save hook output: %v", err)
+			}
+		}
+	}()
+
+	// Выполняем задачу согласно её Type. "" и "directory" архивируют
+	// SourcePath (поведение по умолчанию); "shell" и "curl" — чистые
+	// команды/healthcheck без архива и загрузки; "database" создаёт дамп,
+	// который ниже проходит тот же upload+retention пайплайн, что и архив.
+
+	switch task.Type {
+	case "shell":
+		if err := runShellJob(task, log); err != nil {
+			result.Error = fmt.Sprintf("Shell job failed: %v", err)
+			return sendNotification(err)
+		}
+		result.Success = true
+		return sendNotification(nil)
+
+	case "curl":
+		if err := runCurlCheck(task, log); err != nil {
+			result.Error = fmt.Sprintf("Curl healthcheck failed: %v", err)
+			return sendNotification(err)
+		}
+		result.Success = true
+		return sendNotification(nil)
+
+	case "database":
+		dumpPath, name, err := createDatabaseDump(task, serverIP, log)
 		if err != nil {
-			result.Error = fmt.Sprintf("Failed to create archive: %v", err)
-			return result, err
+			result.Error = fmt.Sprintf("Failed to create database dump: %v", err)
+			return sendNotification(err)
 		}
-		result.ArchivePath = archivePath
+		result.ArchivePath = dumpPath
 		archiveName = name
-		
-		// Сохраняем запись о бэкапе
+
 		backupRecord := BackupRecord{
-			SourcePath:    task.SourcePath,
-			ArchiveName:   archiveName,
-			BackupDate:    time.Now(),
-			ArchiveSizeMB: 0, // Будет обновлено после создания
-			Status:        "creating",
+			BackupID:    backupID,
+			SourcePath:  task.DBName,
+			ArchiveName: archiveName,
+			BackupDate:  time.Now(),
+			Status:      "creating",
 		}
 		if err := SaveBackupRecord(backupRecord, log); err != nil {
 			log.Warnf("Failed to save backup record: %v", err)
 		}
 
-		// Получаем размер архива
-		stat, err := os.Stat(result.ArchivePath)
-		if err == nil {
+		if stat, err := os.Stat(dumpPath); err == nil {
 			result.ArchiveSize = stat.Size()
-			// Обновляем размер в записи
 			records, _ := GetBackupRecords()
 			for i := range records {
 				if records[i].ArchiveName == archiveName {
@@ -99,30 +265,95 @@ func ExecuteBackup(task config.Task, serverIP string, log *logger.Logger) (*Back
 				}
 			}
 		}
+		result.FilesCount = 1
+
+	default: // "", "directory"
+		if task.CreateArchive {
+			if !task.BufferToDisk && hasStorageConfigured(task) {
+				// Стримим архив прямо в хранилище, без буфера на диске
+				name, remotePath, archiveSize, sha256sum, err := streamArchiveToStorage(task, serverIP, backupID, log, &storageBackend)
+				if err != nil {
+					result.Error = fmt.Sprintf("Failed to stream archive: %v", err)
+					return sendNotification(err)
+				}
+				archiveName = name
+				result.S3Path = remotePath
+				result.ArchiveSize = archiveSize
+				result.FilesCount = countFiles(task.SourcePath)
+				streamedToStorage = true
+
+				if err := UpdateBackupRecord(archiveName, remotePath, time.Now(), sha256sum, archiveSize); err != nil {
+					log.Warnf("Failed to update backup record: %v", err)
+				}
+			} else {
+				archivePath, name, err := createArchive(task.SourcePath, task.ArchiveFormat, serverIP, log)
+				if err != nil {
+					result.Error = fmt.Sprintf("Failed to create archive: %v", err)
+					return sendNotification(err)
+				}
+				result.ArchivePath = archivePath
+				archiveName = name
+
+				// Сохраняем запись о бэкапе
+				backupRecord := BackupRecord{
+					BackupID:      backupID,
+					SourcePath:    task.SourcePath,
+					ArchiveName:   archiveName,
+					BackupDate:    time.Now(),
+					ArchiveSizeMB: 0, // Будет обновлено после создания
+					Status:        "creating",
+				}
+				if err := SaveBackupRecord(backupRecord, log); err != nil {
+					log.Warnf("Failed to save backup record: %v", err)
+				}
 
-		// Подсчитываем количество файлов
-		result.FilesCount = countFiles(task.SourcePath)
-	} else {
-		// Если не создаем архив, просто считаем файлы
-		result.FilesCount = countFiles(task.SourcePath)
+				// Получаем размер архива
+				stat, err := os.Stat(result.ArchivePath)
+				if err == nil {
+					result.ArchiveSize = stat.Size()
+					// Обновляем размер в записи
+					records, _ := GetBackupRecords()
+					for i := range records {
+						if records[i].ArchiveName == archiveName {
+							records[i].ArchiveSizeMB = float64(result.ArchiveSize) / (1024 * 1024)
+							break
+						}
+					}
+				}
+
+				// Подсчитываем количество файлов
+				result.FilesCount = countFiles(task.SourcePath)
+			}
+		} else {
+			// Если не создаем архив, просто считаем файлы
+			result.FilesCount = countFiles(task.SourcePath)
+		}
 	}
 
-	// Загружаем в S3
-	if task.S3Endpoint != "" && task.S3Bucket != "" {
+	// Загружаем в хранилище (если архив ещё не был застримлен напрямую выше)
+	if hasStorageConfigured(task) && !streamedToStorage {
+		backend, err := storage.New(task)
+		if err != nil {
+			result.Error = fmt.Sprintf("Failed to init storage backend: %v", err)
+			return sendNotification(err)
+		}
+		defer backend.Close()
+		storageBackend = backend.Name()
+
 		if result.ArchivePath != "" {
 			// Загружаем архив
-			s3Path, err := uploadToS3(task, result.ArchivePath, log)
+			remotePath, err := backend.Upload(context.Background(), result.ArchivePath, filepath.Base(result.ArchivePath))
 			if err != nil {
-				result.Error = fmt.Sprintf("Failed to upload to S3: %v", err)
-				return result, err
+				result.Error = fmt.Sprintf("Failed to upload to %s: %v", backend.Name(), err)
+				return sendNotification(err)
 			}
-			result.S3Path = s3Path
-			
+			result.S3Path = remotePath
+
 			// Обновляем запись о бэкапе
-			if err := UpdateBackupRecord(archiveName, s3Path, time.Now()); err != nil {
+			if err := UpdateBackupRecord(archiveName, remotePath, time.Now(), "", result.ArchiveSize); err != nil {
 				log.Warnf("Failed to update backup record: %v", err)
 			}
-			
+
 			// Удаляем локальный архив после успешной загрузки
 			if err := os.Remove(result.ArchivePath); err != nil {
 				log.Warnf("Failed to remove archive after upload: %v", err)
@@ -131,91 +362,53 @@ func ExecuteBackup(task config.Task, serverIP string, log *logger.Logger) (*Back
 			}
 		} else {
 			// Загружаем файлы напрямую без архива
-			s3Path, err := uploadDirectoryToS3(task, task.SourcePath, log)
+			remotePath, err := uploadDirectory(backend, task.SourcePath, log)
 			if err != nil {
-				result.Error = fmt.Sprintf("Failed to upload directory to S3: %v", err)
-				return result, err
+				result.Error = fmt.Sprintf("Failed to upload directory to %s: %v", backend.Name(), err)
+				return sendNotification(err)
 			}
-			result.S3Path = s3Path
+			result.S3Path = remotePath
 		}
 	}
 
 	// Очистка старых бэкапов
 	if task.CleanupEnabled {
-		if err := cleanupOldBackups(task, log); err != nil {
+		if err := cleanupOldBackups(task, serverIP, log); err != nil {
 			log.Warnf("Failed to cleanup old backups: %v", err)
 		}
 	}
 
 	result.Success = true
-	return result, nil
+	return sendNotification(nil)
 }
 
-func createArchive(sourcePath, format, serverIP string, log *logger.Logger) (string, string, error) {
-	timestamp := time.Now().Format("20060102_150405")
-	// Имя архива: IP_сервера_путь_дата
-	safePath := strings.ReplaceAll(strings.TrimPrefix(sourcePath, "/"), "/", "_")
-	archiveName := fmt.Sprintf("%s_%s_%s.%s", serverIP, safePath, timestamp, format)
-	archivePath := filepath.Join("/tmp", archiveName)
-
-	log.Infof("Creating archive: %s from %s", archivePath, sourcePath)
-
-	file, err := os.Create(archivePath)
+// previousRecords возвращает последние limit сохранённых записей бэкапа для
+// sourcePath в виде notify.RecordSummary, для тренда в шаблонах уведомлений.
+func previousRecords(sourcePath string, limit int) []notify.RecordSummary {
+	records, err := GetBackupRecords()
 	if err != nil {
-		return "", "", err
-	}
-	defer file.Close()
-
-	var writer io.Writer = file
-
-	if format == "tar.gz" {
-		gzipWriter := gzip.NewWriter(file)
-		defer gzipWriter.Close()
-		writer = gzipWriter
+		return nil
 	}
 
-	tarWriter := tar.NewWriter(writer)
-	defer tarWriter.Close()
-
-	err = filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		header, err := tar.FileInfoHeader(info, "")
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(sourcePath, path)
-		if err != nil {
-			return err
-		}
-		header.Name = relPath
-
-		if err := tarWriter.WriteHeader(header); err != nil {
-			return err
-		}
-
-		if !info.Mode().IsRegular() {
-			return nil
+	var matched []BackupRecord
+	for _, r := range records {
+		if r.SourcePath == sourcePath {
+			matched = append(matched, r)
 		}
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
 
-		file, err := os.Open(path)
-		if err != nil {
-			return err
+	summaries := make([]notify.RecordSummary, len(matched))
+	for i, r := range matched {
+		summaries[i] = notify.RecordSummary{
+			BackupDate:    r.BackupDate,
+			ArchiveSizeMB: r.ArchiveSizeMB,
+			Status:        r.Status,
 		}
-		defer file.Close()
-
-		_, err = io.Copy(tarWriter, file)
-		return err
-	})
-	
-	if err != nil {
-		return "", "", err
 	}
-	
-	return archivePath, archiveName, nil
+	return summaries
 }
 
 func countFiles(path string) int {
@@ -229,84 +422,26 @@ func countFiles(path string) int {
 	return count
 }
 
-func uploadToS3(task config.Task, archivePath string, log *logger.Logger) (string, error) {
-	log.Infof("Uploading to S3: %s/%s", task.S3Endpoint, task.S3Bucket)
-
-	// Очищаем endpoint от протокола
-	endpoint := strings.TrimPrefix(strings.TrimPrefix(task.S3Endpoint, "http://"), "https://")
-	useSSL := strings.HasPrefix(task.S3Endpoint, "https://")
-
-	// Создаем клиент MinIO
-	minioClient, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(task.S3AccessKey, task.S3SecretKey, ""),
-		Secure: useSSL,
-		Region: task.S3Region,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to create S3 client: %v", err)
-	}
-
-	ctx := context.Background()
-
-	// Проверяем существование bucket
-	exists, err := minioClient.BucketExists(ctx, task.S3Bucket)
-	if err != nil {
-		return "", fmt.Errorf("failed to check bucket: %v", err)
-	}
-
-	if !exists {
-		if err := minioClient.MakeBucket(ctx, task.S3Bucket, minio.MakeBucketOptions{Region: task.S3Region}); err != nil {
-			return "", fmt.Errorf("failed to create bucket: %v", err)
-		}
-	}
-
-	// Загружаем файл
-	objectName := filepath.Base(archivePath)
-	_, err = minioClient.FPutObject(ctx, task.S3Bucket, objectName, archivePath, minio.PutObjectOptions{})
-	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %v", err)
+// hasStorageConfigured сообщает, указан ли для задачи бэкенд хранения —
+// либо явный StorageType, либо устаревшие S3-поля для обратной совместимости.
+func hasStorageConfigured(task config.Task) bool {
+	if task.StorageType != "" {
+		return true
 	}
-
-	s3Path := fmt.Sprintf("s3://%s/%s", task.S3Bucket, objectName)
-	log.Infof("Successfully uploaded to %s", s3Path)
-
-	return s3Path, nil
+	return task.S3Endpoint != "" && task.S3Bucket != ""
 }
 
-func uploadDirectoryToS3(task config.Task, sourcePath string, log *logger.Logger) (string, error) {
-	log.Infof("Uploading directory to S3: %s/%s", task.S3Endpoint, task.S3Bucket)
-
-	// Очищаем endpoint от протокола
-	endpoint := strings.TrimPrefix(strings.TrimPrefix(task.S3Endpoint, "http://"), "https://")
-	useSSL := strings.HasPrefix(task.S3Endpoint, "https://")
-
-	minioClient, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(task.S3AccessKey, task.S3SecretKey, ""),
-		Secure: useSSL,
-		Region: task.S3Region,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to create S3 client: %v", err)
-	}
+// uploadDirectory загружает все файлы директории напрямую в хранилище, без
+// промежуточного архива.
+func uploadDirectory(backend storage.Backend, sourcePath string, log *logger.Logger) (string, error) {
+	log.Infof("Uploading directory to %s: %s", backend.Name(), sourcePath)
 
 	ctx := context.Background()
-
-	exists, err := minioClient.BucketExists(ctx, task.S3Bucket)
-	if err != nil {
-		return "", fmt.Errorf("failed to check bucket: %v", err)
-	}
-
-	if !exists {
-		if err := minioClient.MakeBucket(ctx, task.S3Bucket, minio.MakeBucketOptions{Region: task.S3Region}); err != nil {
-			return "", fmt.Errorf("failed to create bucket: %v", err)
-		}
-	}
-
-	// Загружаем все файлы из директории
 	timestamp := time.Now().Format("20060102_150405")
 	baseName := filepath.Base(sourcePath)
+	prefix := fmt.Sprintf("%s_%s", baseName, timestamp)
 
-	err = filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -320,9 +455,8 @@ func uploadDirectoryToS3(task config.Task, sourcePath string, log *logger.Logger
 			return err
 		}
 
-		objectName := fmt.Sprintf("%s_%s/%s", baseName, timestamp, relPath)
-		_, err = minioClient.FPutObject(ctx, task.S3Bucket, objectName, path, minio.PutObjectOptions{})
-		if err != nil {
+		remoteKey := fmt.Sprintf("%s/%s", prefix, relPath)
+		if _, err := backend.Upload(ctx, path, remoteKey); err != nil {
 			return fmt.Errorf("failed to upload %s: %v", path, err)
 		}
 
@@ -333,45 +467,50 @@ func uploadDirectoryToS3(task config.Task, sourcePath string, log *logger.Logger
 		return "", err
 	}
 
-	s3Path := fmt.Sprintf("s3://%s/%s_%s/", task.S3Bucket, baseName, timestamp)
-	log.Infof("Successfully uploaded directory to %s", s3Path)
+	remotePath := fmt.Sprintf("%s/%s/", backend.Name(), prefix)
+	log.Infof("Successfully uploaded directory to %s", remotePath)
 
-	return s3Path, nil
+	return remotePath, nil
 }
 
-func cleanupOldBackups(task config.Task, log *logger.Logger) error {
-	log.Infof("Cleaning up backups older than %d days", task.CleanupDays)
+func cleanupOldBackups(task config.Task, serverIP string, log *logger.Logger) error {
+	policy := retention.FromTask(task)
+	log.Infof("Applying retention policy: last=%d hourly=%d daily=%d weekly=%d monthly=%d yearly=%d",
+		policy.KeepLast, policy.KeepHourly, policy.KeepDaily, policy.KeepWeekly, policy.KeepMonthly, policy.KeepYearly)
 
-	// Очищаем endpoint от протокола
-	endpoint := strings.TrimPrefix(strings.TrimPrefix(task.S3Endpoint, "http://"), "https://")
-	useSSL := strings.HasPrefix(task.S3Endpoint, "https://")
-
-	minioClient, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(task.S3AccessKey, task.S3SecretKey, ""),
-		Secure: useSSL,
-		Region: task.S3Region,
-	})
+	backend, err := storage.New(task)
 	if err != nil {
 		return err
 	}
+	defer backend.Close()
 
 	ctx := context.Background()
-	cutoffTime := time.Now().AddDate(0, 0, -task.CleanupDays)
-
-	objectsCh := minioClient.ListObjects(ctx, task.S3Bucket, minio.ListObjectsOptions{
-		Recursive: true,
-	})
-
-	for object := range objectsCh {
-		if object.Err != nil {
-			continue
+	// Ограничиваем список архивами этой задачи (по префиксу имени,
+	// см. archiveNamePrefix и buildDatabaseDumpName) — иначе при общем
+	// бакете/remote-пути на несколько задач retention.Select отбирает бакеты
+	// GFS по всем чужим архивам сразу и удаляет ещё нужные бэкапы других
+	// задач. Бэкенды, кроме s3, трактуют prefix в List как поддиректорию,
+	// поэтому берём список корня хранилища и фильтруем по имени здесь.
+	namePrefix := archiveNamePrefix(task.SourcePath, serverIP)
+	if task.Type == "database" {
+		namePrefix = fmt.Sprintf("%s_%s_", serverIP, strings.ReplaceAll(task.DBName, "/", "_"))
+	}
+	listed, err := backend.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	var objects []storage.Object
+	for _, obj := range listed {
+		if strings.HasPrefix(obj.Key, namePrefix) {
+			objects = append(objects, obj)
 		}
+	}
 
-		if object.LastModified.Before(cutoffTime) {
-			log.Infof("Deleting old backup: %s", object.Key)
-			if err := minioClient.RemoveObject(ctx, task.S3Bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
-				log.Warnf("Failed to delete %s: %v", object.Key, err)
-			}
+	_, toRemove := retention.Select(objects, policy, time.Now())
+	for _, obj := range toRemove {
+		log.Infof("Deleting old backup: %s", obj.Key)
+		if err := backend.Delete(ctx, obj.Key); err != nil {
+			log.Warnf("Failed to delete %s: %v", obj.Key, err)
 		}
 	}
 
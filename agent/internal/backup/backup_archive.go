@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"backup-server-agent/internal/config"
+	"backup-server-agent/internal/logger"
+	"backup-server-agent/internal/storage"
+)
+
+// buildArchiveName формирует имя архива по схеме IP_сервера_путь_дата.
+func buildArchiveName(sourcePath, format, serverIP string) string {
+	return fmt.Sprintf("%s%s.%s", archiveNamePrefix(sourcePath, serverIP), time.Now().Format("20060102_150405"), format)
+}
+
+// archiveNamePrefix возвращает общую часть имён архивов, которые
+// buildArchiveName создаёт для этой задачи (IP_сервера_путь_), — до
+// таймстемпа и расширения. Используется cleanupOldBackups, чтобы
+// ограничить retention.Select только архивами этой задачи, не затрагивая
+// другие задачи, делящие то же хранилище.
+func archiveNamePrefix(sourcePath, serverIP string) string {
+	safePath := strings.ReplaceAll(strings.TrimPrefix(sourcePath, "/"), "/", "_")
+	return fmt.Sprintf("%s_%s_", serverIP, safePath)
+}
+
+// writeTarArchive пишет содержимое sourcePath в виде tar-потока в writer.
+func writeTarArchive(sourcePath string, writer io.Writer) error {
+	tarWriter := tar.NewWriter(writer)
+	defer tarWriter.Close()
+
+	return filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+}
+
+// createArchive пишет архив на диск в /tmp и возвращает его путь и имя. Это
+// буферизованный на диске путь, используемый когда Task.BufferToDisk=true
+// или когда для задачи не настроено хранилище.
+func createArchive(sourcePath, format, serverIP string, log *logger.Logger) (string, string, error) {
+	archiveName := buildArchiveName(sourcePath, format, serverIP)
+	archivePath := filepath.Join("/tmp", archiveName)
+
+	log.Infof("Creating archive: %s from %s", archivePath, sourcePath)
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	var writer io.Writer = file
+	if format == "tar.gz" {
+		gzipWriter := gzip.NewWriter(file)
+		defer gzipWriter.Close()
+		writer = gzipWriter
+	}
+
+	if err := writeTarArchive(sourcePath, writer); err != nil {
+		return "", "", err
+	}
+
+	return archivePath, archiveName, nil
+}
+
+// StreamArchive запускает архивирование sourcePath в фоновой горутине,
+// пишущей в io.Pipe, так что вызывающий код может отдавать архив в
+// хранилище по мере его создания, не буферизуя его целиком на диске.
+func StreamArchive(ctx context.Context, sourcePath, format string) (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+
+	go func() {
+		var writer io.Writer = pw
+		var gzipWriter *gzip.Writer
+		if format == "tar.gz" {
+			gzipWriter = gzip.NewWriter(pw)
+			writer = gzipWriter
+		}
+
+		err := writeTarArchive(sourcePath, writer)
+
+		if gzipWriter != nil {
+			if closeErr := gzipWriter.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		pw.CloseWithError(err)
+	}()
+
+	return pr, nil
+}
+
+// hashingReader оборачивает io.Reader, считая прочитанные байты и их
+// SHA-256 по ходу чтения, без повторного прохода по данным.
+type hashingReader struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+}
+
+func newHashingReader(r io.Reader) *hashingReader {
+	return &hashingReader{r: r, hash: sha256.New()}
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if n > 0 {
+		h.size += int64(n)
+		h.hash.Write(p[:n])
+	}
+	return n, err
+}
+
+func (h *hashingReader) sum() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// streamArchiveToStorage архивирует task.SourcePath и стримит результат
+// напрямую в backend хранилища задачи, вычисляя размер и SHA-256 по ходу
+// передачи. Возвращает имя архива, путь в хранилище, размер и контрольную
+// сумму.
+func streamArchiveToStorage(task config.Task, serverIP, backupID string, log *logger.Logger, storageBackendName *string) (string, string, int64, string, error) {
+	backend, err := storage.New(task)
+	if err != nil {
+		return "", "", 0, "", err
+	}
+	defer backend.Close()
+	*storageBackendName = backend.Name()
+
+	archiveName := buildArchiveName(task.SourcePath, task.ArchiveFormat, serverIP)
+	log.Infof("Streaming archive %s from %s to %s", archiveName, task.SourcePath, backend.Name())
+
+	backupRecord := BackupRecord{
+		BackupID:    backupID,
+		SourcePath:  task.SourcePath,
+		ArchiveName: archiveName,
+		BackupDate:  time.Now(),
+		Status:      "creating",
+	}
+	if err := SaveBackupRecord(backupRecord, log); err != nil {
+		log.Warnf("Failed to save backup record: %v", err)
+	}
+
+	archiveReader, err := StreamArchive(context.Background(), task.SourcePath, task.ArchiveFormat)
+	if err != nil {
+		return archiveName, "", 0, "", fmt.Errorf("failed to start archive stream: %v", err)
+	}
+	defer archiveReader.Close()
+
+	hashed := newHashingReader(archiveReader)
+	remotePath, err := backend.UploadStream(context.Background(), archiveName, hashed, -1)
+	if err != nil {
+		return archiveName, "", 0, "", fmt.Errorf("failed to upload stream: %v", err)
+	}
+
+	return archiveName, remotePath, hashed.size, hashed.sum(), nil
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestHashingReader проверяет, что hashingReader считает размер и SHA-256
+// прочитанных данных по ходу чтения, не искажая сам поток.
+func TestHashingReader(t *testing.T) {
+	data := bytes.Repeat([]byte("stream-me"), 100000) // ~900KB
+	want := sha256.Sum256(data)
+
+	hr := newHashingReader(bytes.NewReader(data))
+	got, err := io.ReadAll(hr)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("hashingReader altered the stream: got %d bytes, want %d", len(got), len(data))
+	}
+	if hr.size != int64(len(data)) {
+		t.Errorf("size = %d, want %d", hr.size, len(data))
+	}
+	if hr.sum() != hex.EncodeToString(want[:]) {
+		t.Errorf("sha256 = %s, want %s", hr.sum(), hex.EncodeToString(want[:]))
+	}
+}
+
+// TestStreamArchiveLargeFile проверяет, что StreamArchive производит валидный
+// tar-поток для файла, заметно превышающего размер одного чтения из
+// io.Pipe, — без дедлоков и потери данных при чтении через hashingReader,
+// как это происходит в streamArchiveToStorage.
+func TestStreamArchiveLargeFile(t *testing.T) {
+	dir := t.TempDir()
+	large := bytes.Repeat([]byte("0123456789"), 300000) // ~3MB
+	if err := os.WriteFile(filepath.Join(dir, "big.bin"), large, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "small.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	rc, err := StreamArchive(context.Background(), dir, "tar")
+	if err != nil {
+		t.Fatalf("StreamArchive: %v", err)
+	}
+	defer rc.Close()
+
+	hashed := newHashingReader(rc)
+	tr := tar.NewReader(hashed)
+
+	found := map[string]int64{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar.Next: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		n, err := io.Copy(io.Discard, tr)
+		if err != nil {
+			t.Fatalf("io.Copy: %v", err)
+		}
+		found[hdr.Name] = n
+	}
+
+	if found["big.bin"] != int64(len(large)) {
+		t.Errorf("big.bin size in archive = %d, want %d", found["big.bin"], len(large))
+	}
+	if found["small.txt"] != 5 {
+		t.Errorf("small.txt size in archive = %d, want 5", found["small.txt"])
+	}
+	if hashed.size == 0 {
+		t.Errorf("expected hashingReader to observe streamed bytes")
+	}
+}
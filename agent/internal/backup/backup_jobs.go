@@ -0,0 +1,156 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"backup-server-agent/internal/config"
+	"backup-server-agent/internal/logger"
+)
+
+// runShellJob выполняет task.ShellCommand через sh -c — на хосте, либо,
+// если задан task.ScriptContainer, внутри этого контейнера через docker
+// exec.
+func runShellJob(task config.Task, log *logger.Logger) error {
+	if task.ShellCommand == "" {
+		return fmt.Errorf("shell_command is empty")
+	}
+
+	var cmd *exec.Cmd
+	if task.ScriptContainer != "" {
+		cmd = exec.Command("docker", "exec", task.ScriptContainer, "sh", "-c", task.ShellCommand)
+	} else {
+		cmd = exec.Command("sh", "-c", task.ShellCommand)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		log.Infof("%s", output)
+	}
+	if err != nil {
+		return fmt.Errorf("shell command failed: %v", err)
+	}
+	return nil
+}
+
+// runCurlCheck выполняет HTTP-запрос к task.CurlURL и считает задачу
+// проваленной, если код ответа не входит в task.CurlExpectStatus (или, если
+// он не задан, не является 2xx).
+func runCurlCheck(task config.Task, log *logger.Logger) error {
+	if task.CurlURL == "" {
+		return fmt.Errorf("curl_url is empty")
+	}
+
+	method := task.CurlMethod
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	timeout := 30 * time.Second
+	if task.CurlTimeout != "" {
+		if d, err := time.ParseDuration(task.CurlTimeout); err == nil {
+			timeout = d
+		} else {
+			log.Warnf("Invalid curl_timeout %q, using default: %v", task.CurlTimeout, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, task.CurlURL, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range task.CurlHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Infof("Curl healthcheck %s %s -> %s", method, task.CurlURL, resp.Status)
+
+	if len(task.CurlExpectStatus) > 0 {
+		for _, code := range task.CurlExpectStatus {
+			if resp.StatusCode == code {
+				return nil
+			}
+		}
+		return fmt.Errorf("curl healthcheck %s returned %s, expected one of %v", task.CurlURL, resp.Status, task.CurlExpectStatus)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("curl healthcheck %s returned %s", task.CurlURL, resp.Status)
+	}
+	return nil
+}
+
+// buildDatabaseDumpName формирует имя файла дампа по той же схеме
+// IP_имя_дата, что и buildArchiveName.
+func buildDatabaseDumpName(dbName, serverIP string) string {
+	timestamp := time.Now().Format("20060102_150405")
+	safeName := strings.ReplaceAll(dbName, "/", "_")
+	return fmt.Sprintf("%s_%s_%s.sql", serverIP, safeName, timestamp)
+}
+
+// createDatabaseDump выполняет mysqldump/pg_dump (по task.DBDriver),
+// записывая его stdout в файл на диске, и возвращает путь и имя дампа — в
+// том же виде, в каком createArchive отдаёт архив директории, чтобы дамп
+// прошёл через тот же upload+retention пайплайн.
+func createDatabaseDump(task config.Task, serverIP string, log *logger.Logger) (string, string, error) {
+	dumpName := buildDatabaseDumpName(task.DBName, serverIP)
+	dumpPath := filepath.Join("/tmp", dumpName)
+
+	var cmd *exec.Cmd
+	switch task.DBDriver {
+	case "", "mysql":
+		args := []string{"-h", task.DBHost, "-u", task.DBUser}
+		if task.DBPort > 0 {
+			args = append(args, "-P", strconv.Itoa(task.DBPort))
+		}
+		if task.DBPassword != "" {
+			args = append(args, "-p"+task.DBPassword)
+		}
+		args = append(args, task.DBName)
+		cmd = exec.Command("mysqldump", args...)
+	case "postgres":
+		args := []string{"-h", task.DBHost, "-U", task.DBUser}
+		if task.DBPort > 0 {
+			args = append(args, "-p", strconv.Itoa(task.DBPort))
+		}
+		args = append(args, task.DBName)
+		cmd = exec.Command("pg_dump", args...)
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+task.DBPassword)
+	default:
+		return "", "", fmt.Errorf("unknown db driver %q", task.DBDriver)
+	}
+
+	log.Infof("Creating database dump: %s (%s, db=%s)", dumpPath, task.DBDriver, task.DBName)
+
+	file, err := os.Create(dumpPath)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	cmd.Stdout = file
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("%s: %v: %s", filepath.Base(cmd.Path), err, stderr.String())
+	}
+
+	return dumpPath, dumpName, nil
+}
@@ -1,6 +1,7 @@
 package backup
 
 import (
+	"backup-server-agent/internal/hooks"
 	"backup-server-agent/internal/logger"
 	"encoding/json"
 	"os"
@@ -9,13 +10,18 @@ import (
 )
 
 type BackupRecord struct {
+	BackupID      string    `json:"backup_id,omitempty"`
 	SourcePath    string    `json:"source_path"`
 	ArchiveName   string    `json:"archive_name"`
 	BackupDate    time.Time `json:"backup_date"`
 	S3UploadDate  *time.Time `json:"s3_upload_date,omitempty"`
 	ArchiveSizeMB float64   `json:"archive_size_mb"`
+	ArchiveSHA256 string    `json:"archive_sha256,omitempty"`
 	S3Path        string    `json:"s3_path,omitempty"`
 	Status        string    `json:"status"`
+	// HookResults — вывод pre/post-хуков (internal/hooks), выполненных для
+	// этого запуска, для отладки через records API.
+	HookResults []hooks.Result `json:"hook_results,omitempty"`
 }
 
 const backupStorageFile = "/var/lib/backup-server-agent/backups.json"
@@ -59,7 +65,7 @@ func GetBackupRecords() ([]BackupRecord, error) {
 	return records, nil
 }
 
-func UpdateBackupRecord(archiveName string, s3Path string, uploadDate time.Time) error {
+func UpdateBackupRecord(archiveName string, s3Path string, uploadDate time.Time, sha256 string, archiveSize int64) error {
 	records, err := GetBackupRecords()
 	if err != nil {
 		return err
@@ -71,6 +77,12 @@ func UpdateBackupRecord(archiveName string, s3Path string, uploadDate time.Time)
 			records[i].S3Path = s3Path
 			records[i].S3UploadDate = &uploadDate
 			records[i].Status = "success"
+			if sha256 != "" {
+				records[i].ArchiveSHA256 = sha256
+			}
+			if archiveSize > 0 {
+				records[i].ArchiveSizeMB = float64(archiveSize) / (1024 * 1024)
+			}
 			break
 		}
 	}
@@ -84,6 +96,31 @@ func UpdateBackupRecord(archiveName string, s3Path string, uploadDate time.Time)
 	return os.WriteFile(backupStorageFile, data, 0644)
 }
 
+// UpdateBackupRecordHooks сохраняет вывод pre/post-хуков в запись бэкапа по
+// имени архива, чтобы он был доступен через records API.
+func UpdateBackupRecordHooks(archiveName string, results []hooks.Result) error {
+	if archiveName == "" || len(results) == 0 {
+		return nil
+	}
+
+	records, err := GetBackupRecords()
+	if err != nil {
+		return err
+	}
 
+	for i := range records {
+		if records[i].ArchiveName == archiveName {
+			records[i].HookResults = results
+			break
+		}
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(backupStorageFile, data, 0644)
+}
 
 
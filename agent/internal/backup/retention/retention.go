@@ -0,0 +1,146 @@
+// Package retention реализует отбор бэкапов для удаления по политике
+// "grandfather-father-son": keepLast + почасовые/ежедневные/еженедельные/
+// ежемесячные/ежегодные бакеты, плюс минимальный возраст-грейс-период.
+package retention
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"backup-server-agent/internal/config"
+	"backup-server-agent/internal/storage"
+)
+
+// Policy — параметры отбора в разобранном виде (MinAge уже time.Duration).
+type Policy struct {
+	KeepLast    int
+	KeepHourly  int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+	MinAge      time.Duration
+}
+
+// timestampPattern вытаскивает <YYYYMMDD_HHMMSS> из имени архива вида
+// <ip>_<путь>_<YYYYMMDD_HHMMSS>.<ext>, формируемого backup.buildArchiveName.
+var timestampPattern = regexp.MustCompile(`_(\d{8}_\d{6})\.[^_]+$`)
+
+// FromTask строит Policy из Task.Retention, подставляя устаревшее
+// CleanupDays как KeepDaily, если новая политика не задана явно.
+func FromTask(task config.Task) Policy {
+	p := Policy{
+		KeepLast:    task.Retention.KeepLast,
+		KeepHourly:  task.Retention.KeepHourly,
+		KeepDaily:   task.Retention.KeepDaily,
+		KeepWeekly:  task.Retention.KeepWeekly,
+		KeepMonthly: task.Retention.KeepMonthly,
+		KeepYearly:  task.Retention.KeepYearly,
+	}
+
+	if task.Retention.MinAge != "" {
+		if d, err := time.ParseDuration(task.Retention.MinAge); err == nil {
+			p.MinAge = d
+		}
+	}
+
+	if p.KeepLast == 0 && p.KeepHourly == 0 && p.KeepDaily == 0 &&
+		p.KeepWeekly == 0 && p.KeepMonthly == 0 && p.KeepYearly == 0 &&
+		task.CleanupDays > 0 {
+		p.KeepDaily = task.CleanupDays
+	}
+
+	return p
+}
+
+// Select разбирает objects по политике и возвращает, что оставить (keep) и
+// что удалить (remove). Объект считается "сохранённым", если он первым
+// заполняет один из бакетов (keepLast-счётчик, новый час, новая ISO-неделя,
+// новый месяц, новый год) либо попадает внутрь MinAge.
+func Select(objects []storage.Object, policy Policy, now time.Time) (keep, remove []storage.Object) {
+	type entry struct {
+		obj storage.Object
+		ts  time.Time
+	}
+
+	entries := make([]entry, len(objects))
+	for i, o := range objects {
+		entries[i] = entry{obj: o, ts: parseTimestamp(o.Key, o.ModTime)}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ts.After(entries[j].ts) })
+
+	seenHour := make(map[string]bool)
+	seenDay := make(map[string]bool)
+	seenWeek := make(map[string]bool)
+	seenMonth := make(map[string]bool)
+	seenYear := make(map[string]bool)
+	lastCount := 0
+
+	for _, e := range entries {
+		keepThis := false
+
+		if policy.MinAge > 0 && now.Sub(e.ts) < policy.MinAge {
+			keepThis = true
+		}
+
+		if policy.KeepLast > 0 && lastCount < policy.KeepLast {
+			lastCount++
+			keepThis = true
+		}
+
+		if bucketed(seenHour, e.ts.Format("2006010215"), policy.KeepHourly) {
+			keepThis = true
+		}
+		if bucketed(seenDay, e.ts.Format("20060102"), policy.KeepDaily) {
+			keepThis = true
+		}
+		if bucketed(seenWeek, isoWeekKey(e.ts), policy.KeepWeekly) {
+			keepThis = true
+		}
+		if bucketed(seenMonth, e.ts.Format("200601"), policy.KeepMonthly) {
+			keepThis = true
+		}
+		if bucketed(seenYear, e.ts.Format("2006"), policy.KeepYearly) {
+			keepThis = true
+		}
+
+		if keepThis {
+			keep = append(keep, e.obj)
+		} else {
+			remove = append(remove, e.obj)
+		}
+	}
+
+	return keep, remove
+}
+
+// bucketed сообщает, заполняет ли key ещё не заполненный бакет, и если да —
+// отмечает его занятым. limit <= 0 отключает бакет.
+func bucketed(seen map[string]bool, key string, limit int) bool {
+	if limit <= 0 || seen[key] || len(seen) >= limit {
+		return false
+	}
+	seen[key] = true
+	return true
+}
+
+func isoWeekKey(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func parseTimestamp(key string, fallback time.Time) time.Time {
+	match := timestampPattern.FindStringSubmatch(filepath.Base(key))
+	if match == nil {
+		return fallback
+	}
+	t, err := time.ParseInLocation("20060102_150405", match[1], time.Local)
+	if err != nil {
+		return fallback
+	}
+	return t
+}
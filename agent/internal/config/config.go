@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -20,13 +21,59 @@ type Config struct {
 	// Настройки хранилища по умолчанию для агента
 	DefaultStorageType   string `json:"default_storage_type,omitempty"`   // s3, sftp, nfs, local
 	DefaultStorageConfig string `json:"default_storage_config,omitempty"` // JSON строка с настройками хранилища
+	// Уведомления об итогах бэкапа
+	Notifications NotificationsConfig `json:"notifications,omitempty"`
+	// Настройки логирования
+	LogFormat string `json:"log_format,omitempty"` // text|json, по умолчанию text
+	LogLevel  string `json:"log_level,omitempty"`  // debug|info|warn|error, по умолчанию info
+	LogFile   string `json:"log_file,omitempty"`   // если задан, лог дублируется в ротируемый файл
+	// MaxConcurrentBackups ограничивает число одновременных тяжёлых операций
+	// (архивирование/аплоад) во всём агенте через internal/lock. 0 — без
+	// ограничения.
+	MaxConcurrentBackups int `json:"max_concurrent_backups,omitempty"`
+}
+
+// NotificationsConfig описывает куда и когда агент шлёт уведомления об
+// итогах бэкапа через shoutrrr (internal/notify).
+type NotificationsConfig struct {
+	URLs            []string `json:"urls,omitempty"`             // shoutrrr URLs: telegram://, slack://, discord://...
+	SuccessTemplate string   `json:"success_template,omitempty"`  // text/template, пусто = встроенный шаблон
+	FailureTemplate string   `json:"failure_template,omitempty"`  // text/template, пусто = встроенный шаблон
+	Trigger         string   `json:"trigger,omitempty"`           // always|on_failure|on_success, по умолчанию always
+	TrendCount      int      `json:"trend_count,omitempty"`       // сколько прошлых бэкапов передавать в шаблон, по умолчанию 5
 }
 
 type Task struct {
 	TaskID           int    `json:"task_id"`
+	// Type выбирает, что именно делает задача: "" и "directory" архивируют
+	// SourcePath (поведение по умолчанию, для обратной совместимости),
+	// "shell" выполняет ShellCommand, "curl" — HTTP healthcheck по CurlURL,
+	// "database" — дамп БД через mysqldump/pg_dump, загружаемый тем же
+	// archive+storage пайплайном, что и обычный архив. См.
+	// backup.ExecuteBackup.
+	Type             string `json:"type,omitempty"`
 	SourcePath       string `json:"source_path"`
 	CreateArchive    bool   `json:"create_archive"`
 	ArchiveFormat    string `json:"archive_format"`
+	// Поля задачи Type="shell": команда выполняется через sh -c, либо
+	// внутри контейнера ScriptContainer через docker exec, если он задан.
+	ShellCommand string `json:"shell_command,omitempty"`
+	// Поля задачи Type="curl": HTTP-запрос к CurlURL, не-2xx (если
+	// CurlExpectStatus не задан) или код вне CurlExpectStatus считается
+	// ошибкой задачи.
+	CurlURL          string            `json:"curl_url,omitempty"`
+	CurlMethod       string            `json:"curl_method,omitempty"` // по умолчанию GET
+	CurlHeaders      map[string]string `json:"curl_headers,omitempty"`
+	CurlTimeout      string            `json:"curl_timeout,omitempty"` // time.ParseDuration, например "10s"
+	CurlExpectStatus []int             `json:"curl_expect_status,omitempty"`
+	// Поля задачи Type="database": DBDriver — "mysql" (по умолчанию) или
+	// "postgres", выбирает mysqldump/pg_dump.
+	DBDriver   string `json:"db_driver,omitempty"`
+	DBHost     string `json:"db_host,omitempty"`
+	DBPort     int    `json:"db_port,omitempty"`
+	DBName     string `json:"db_name,omitempty"`
+	DBUser     string `json:"db_user,omitempty"`
+	DBPassword string `json:"db_password,omitempty"`
 	// S3 настройки (для обратной совместимости)
 	S3Endpoint       string `json:"s3_endpoint"`
 	S3AccessKey      string `json:"s3_access_key"`
@@ -36,11 +83,91 @@ type Task struct {
 	// Универсальные настройки хранилища
 	StorageType      string `json:"storage_type"` // s3, sftp, nfs, local
 	StorageConfig    string `json:"storage_config"` // JSON строка с настройками хранилища
+	// Переопределение шаблонов уведомлений (internal/notify) для конкретной
+	// задачи — путь к файлу с text/template. Пусто = глобальный шаблон из
+	// Config.Notifications.
+	NotifySuccessTemplatePath string `json:"notify_success_template_path,omitempty"`
+	NotifyFailureTemplatePath string `json:"notify_failure_template_path,omitempty"`
+	// BufferToDisk заставляет архив сначала записываться в /tmp и только потом
+	// загружаться в хранилище, вместо потоковой загрузки. Нужно бэкендам или
+	// форматам, которым требуется произвольный доступ к файлу.
+	BufferToDisk     bool   `json:"buffer_to_disk,omitempty"`
 	CleanupEnabled   bool   `json:"cleanup_enabled"`
-	CleanupDays      int    `json:"cleanup_days"`
+	// Deprecated: заменено Retention.KeepDaily. Оставлено для обратной
+	// совместимости — если Retention не задан, CleanupDays трактуется как
+	// KeepDaily (см. internal/backup/retention.FromTask).
+	CleanupDays      int             `json:"cleanup_days"`
+	Retention        RetentionPolicy `json:"retention,omitempty"`
+	// Deprecated: синтезируется в PreHooks/PostHooks при загрузке конфига
+	// (см. synthesizeComposeHooks), оставлено в JSON для обратной совместимости.
 	IsDockerCompose  bool   `json:"is_docker_compose"`
 	DockerComposePath string `json:"docker_compose_path"`
+	PreHooks         []Hook `json:"pre_hooks,omitempty"`
+	PostHooks        []Hook `json:"post_hooks,omitempty"`
+	// Упрощённый вариант хуков для простого случая "скрипт + остановка
+	// контейнеров": синтезируется в PreHooks/PostHooks при загрузке конфига
+	// (см. synthesizeScriptHooks). Для произвольной последовательности
+	// хуков разных типов используйте PreHooks/PostHooks напрямую.
+	PreScript           string   `json:"pre_script,omitempty"`
+	PostScript          string   `json:"post_script,omitempty"`
+	ScriptContainer     string   `json:"script_container,omitempty"` // если задан, скрипт выполняется через docker exec в этом контейнере
+	StopContainers      []string `json:"stop_containers,omitempty"`  // имена контейнеров либо один label-селектор "key=value"
+	ContinueOnHookError bool     `json:"continue_on_hook_error,omitempty"`
 	ScheduleCron     string `json:"schedule_cron"`
+	// RecordRetention ограничивает число и возраст хранимых записей о
+	// выполнении задачи (internal/execution) — прунинг применяется после
+	// каждого запуска, отдельно от Retention, который отбирает архивы в
+	// хранилище.
+	RecordRetention RecordRetentionPolicy `json:"record_retention,omitempty"`
+	// Конкурентная защита (internal/lock): при перекрывающемся запуске
+	// задачи (cron-тик, системный crontab --task-id, HTTP
+	// /api/task/execute) SkipIfRunning=true сразу пропускает новый запуск;
+	// иначе он ждёт освобождения блокировки до MaxWait (пусто — ждать,
+	// пока не получится) и пропускается, если так и не дождался.
+	SkipIfRunning bool   `json:"skip_if_running,omitempty"`
+	MaxWait       string `json:"max_wait,omitempty"` // time.ParseDuration, например "5m"
+	// Paused отключает расписание задачи (cron.CronManager.Pause/Resume) без
+	// удаления самой задачи — ручной запуск (/api/task/:id/run) по-прежнему
+	// доступен.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// RecordRetentionPolicy описывает политику хранения записей о выполнении
+// задачи (internal/execution): сколько последних запусков хранить и сколько
+// дней хранить их, независимо от количества. Ноль отключает соответствующий
+// лимит.
+type RecordRetentionPolicy struct {
+	KeepRecords int `json:"keep_records,omitempty"`
+	KeepDays    int `json:"keep_days,omitempty"`
+}
+
+// Hook описывает команду, выполняемую до (PreHooks) или после (PostHooks)
+// бэкапа — например, остановку контейнеров перед снятием снапшота тома.
+// PreHooks выполняются по порядку до архивирования, PostHooks — в обратном
+// порядке после загрузки в хранилище. Вывод выполнения хуков идёт через
+// internal/hooks в структурированный логгер с атрибутом "hook".
+type Hook struct {
+	Type            string   `json:"type"` // exec|docker_stop|docker_exec|http
+	Command         string   `json:"command"`
+	Args            []string `json:"args,omitempty"`
+	Timeout         string   `json:"timeout,omitempty"` // time.ParseDuration, например "30s"
+	ContinueOnError bool     `json:"continue_on_error,omitempty"`
+}
+
+// RetentionPolicy описывает политику хранения бэкапов по схеме
+// "grandfather-father-son": сколько последних/почасовых/ежедневных/
+// еженедельных/ежемесячных/ежегодных снапшотов сохранять, плюс необязательный
+// минимальный возраст (grace period), в течение которого бэкап не удаляется
+// независимо от остальных правил. Реализация отбора — в
+// internal/backup/retention.
+type RetentionPolicy struct {
+	KeepLast    int    `json:"keep_last,omitempty"`
+	KeepHourly  int    `json:"keep_hourly,omitempty"`
+	KeepDaily   int    `json:"keep_daily,omitempty"`
+	KeepWeekly  int    `json:"keep_weekly,omitempty"`
+	KeepMonthly int    `json:"keep_monthly,omitempty"`
+	KeepYearly  int    `json:"keep_yearly,omitempty"`
+	MinAge      string `json:"min_age,omitempty"` // time.ParseDuration, например "72h"
 }
 
 func Load() (*Config, error) {
@@ -70,9 +197,77 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %v", err)
 	}
 
+	for i := range cfg.Tasks {
+		synthesizeComposeHooks(&cfg.Tasks[i])
+		synthesizeScriptHooks(&cfg.Tasks[i])
+	}
+
 	return &cfg, nil
 }
 
+// synthesizeComposeHooks переписывает устаревшие IsDockerCompose/
+// DockerComposePath в пару pre/post hook'ов типа "exec", воспроизводящих
+// прежнее поведение (down перед бэкапом, up -d после). Ничего не делает,
+// если хуки уже заданы явно.
+func synthesizeComposeHooks(t *Task) {
+	if !t.IsDockerCompose || t.DockerComposePath == "" {
+		return
+	}
+	if len(t.PreHooks) > 0 || len(t.PostHooks) > 0 {
+		return
+	}
+
+	down := fmt.Sprintf("docker compose -f %s down || docker-compose -f %s down", t.DockerComposePath, t.DockerComposePath)
+	up := fmt.Sprintf("docker compose -f %s up -d || docker-compose -f %s up -d", t.DockerComposePath, t.DockerComposePath)
+
+	t.PreHooks = []Hook{{Type: "exec", Command: "sh", Args: []string{"-c", down}, ContinueOnError: true}}
+	t.PostHooks = []Hook{{Type: "exec", Command: "sh", Args: []string{"-c", up}, ContinueOnError: true}}
+}
+
+// synthesizeScriptHooks переписывает PreScript/PostScript/StopContainers в
+// пару pre/post hook'ов: сперва скрипт (на хосте или, если задан
+// ScriptContainer, внутри контейнера через docker_exec), затем остановка
+// контейнеров (docker_stop), перезапускаемых симметричным хуком в
+// PostHooks. Ничего не делает, если хуки уже заданы явно.
+func synthesizeScriptHooks(t *Task) {
+	if t.PreScript == "" && t.PostScript == "" && len(t.StopContainers) == 0 {
+		return
+	}
+	if len(t.PreHooks) > 0 || len(t.PostHooks) > 0 {
+		return
+	}
+
+	scriptHook := func(script string) Hook {
+		if t.ScriptContainer != "" {
+			return Hook{Type: "docker_exec", Command: t.ScriptContainer, Args: []string{"sh", "-c", script}, ContinueOnError: t.ContinueOnHookError}
+		}
+		return Hook{Type: "exec", Command: "sh", Args: []string{"-c", script}, ContinueOnError: t.ContinueOnHookError}
+	}
+
+	var stopHook *Hook
+	if len(t.StopContainers) > 0 {
+		h := Hook{Type: "docker_stop", Command: strings.Join(t.StopContainers, ","), ContinueOnError: t.ContinueOnHookError}
+		stopHook = &h
+	}
+
+	if t.PreScript != "" {
+		t.PreHooks = append(t.PreHooks, scriptHook(t.PreScript))
+	}
+	if stopHook != nil {
+		t.PreHooks = append(t.PreHooks, *stopHook)
+	}
+
+	// PostHooks выполняются в обратном порядке (internal/hooks.RunPost),
+	// поэтому стоп-хук кладём последним — он выполнится первым и
+	// перезапустит контейнеры перед пост-скриптом.
+	if t.PostScript != "" {
+		t.PostHooks = append(t.PostHooks, scriptHook(t.PostScript))
+	}
+	if stopHook != nil {
+		t.PostHooks = append(t.PostHooks, *stopHook)
+	}
+}
+
 func Save(cfg *Config) error {
 	// Создаем директорию, если её нет
 	if err := os.MkdirAll(ConfigDir, 0755); err != nil {
@@ -121,6 +316,18 @@ func (c *Config) GetTask(taskID int) *Task {
 	return nil
 }
 
+// SetTaskPaused переключает Task.Paused для taskID, сообщая, нашлась ли
+// задача.
+func (c *Config) SetTaskPaused(taskID int, paused bool) bool {
+	for i := range c.Tasks {
+		if c.Tasks[i].TaskID == taskID {
+			c.Tasks[i].Paused = paused
+			return true
+		}
+	}
+	return false
+}
+
 
 
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"backup-server-agent/internal/backup"
 	"backup-server-agent/internal/config"
@@ -11,6 +12,16 @@ import (
 	"github.com/robfig/cron/v3"
 )
 
+// EntryInfo описывает состояние расписания одной задачи — для
+// GET /api/cron/entries.
+type EntryInfo struct {
+	TaskID   int       `json:"task_id"`
+	Schedule string    `json:"schedule"`
+	Next     time.Time `json:"next,omitempty"`
+	Prev     time.Time `json:"prev,omitempty"`
+	Paused   bool      `json:"paused"`
+}
+
 type CronManager struct {
 	cron    *cron.Cron
 	config  *config.Config
@@ -39,9 +50,14 @@ func (cm *CronManager) Stop() {
 
 func (cm *CronManager) LoadTasks() error {
 	for _, task := range cm.config.Tasks {
-		if task.ScheduleCron != "" {
-			cm.AddTask(task)
+		if task.ScheduleCron == "" {
+			continue
+		}
+		if task.Paused {
+			cm.updateSystemCron(task)
+			continue
 		}
+		cm.AddTask(task)
 	}
 	return nil
 }
@@ -54,18 +70,25 @@ func (cm *CronManager) AddTask(task config.Task) {
 
 	// Добавляем новую задачу
 	entryID, err := cm.cron.AddFunc(task.ScheduleCron, func() {
-		cm.logger.Infof("Executing backup task %d: %s", task.TaskID, task.SourcePath)
+		taskType := task.Type
+		if taskType == "" {
+			taskType = "directory"
+		}
+		cm.logger.Infof("Executing %s task %d: %s", taskType, task.TaskID, task.SourcePath)
 		serverIP := cm.config.ServerIP
 		if serverIP == "" {
 			serverIP = "unknown"
 		}
-		result, err := backup.ExecuteBackup(task, serverIP, cm.logger)
+		result, message, err := backup.ExecuteBackup(task, serverIP, cm.logger, cm.config)
 		if err != nil {
 			cm.logger.Errorf("Backup task %d failed: %v", task.TaskID, err)
 		} else if result.Success {
-			cm.logger.Infof("Backup task %d completed successfully. Size: %d bytes, Files: %d", 
+			cm.logger.Infof("Backup task %d completed successfully. Size: %d bytes, Files: %d",
 				task.TaskID, result.ArchiveSize, result.FilesCount)
 		}
+		if message != "" {
+			cm.logger.Infof("Notification sent for task %d: %s", task.TaskID, message)
+		}
 	})
 
 	if err != nil {
@@ -80,6 +103,75 @@ func (cm *CronManager) AddTask(task config.Task) {
 	cm.updateSystemCron(task)
 }
 
+// Pause снимает задачу с расписания, не удаляя её из конфига — сама задача
+// (и статус Paused) по-прежнему хранится в config.Config, так что Resume
+// возвращает её тем же ScheduleCron. Системную crontab-запись при этом не
+// удаляем, а комментируем (см. updateSystemCron); строка формируется заново
+// из ScheduleCron, так что ручные правки в ней не сохраняются.
+func (cm *CronManager) Pause(taskID int) {
+	if entryID, exists := cm.entries[taskID]; exists {
+		cm.cron.Remove(entryID)
+		delete(cm.entries, taskID)
+		cm.logger.Infof("Paused cron task %d", taskID)
+	}
+	if task := cm.config.GetTask(taskID); task != nil {
+		cm.updateSystemCron(*task)
+	}
+}
+
+// Resume возвращает ранее приостановленную задачу в расписание.
+func (cm *CronManager) Resume(taskID int) {
+	task := cm.config.GetTask(taskID)
+	if task == nil || task.ScheduleCron == "" {
+		return
+	}
+	cm.AddTask(*task)
+}
+
+// NextRun возвращает время следующего срабатывания задачи taskID, либо
+// нулевое время, если задача не стоит в расписании (снята или
+// приостановлена).
+func (cm *CronManager) NextRun(taskID int) time.Time {
+	if entryID, exists := cm.entries[taskID]; exists {
+		return cm.cron.Entry(entryID).Next
+	}
+	return time.Time{}
+}
+
+// Entries перечисляет все задачи с ScheduleCron вместе с их next/prev
+// временем срабатывания (cron.Cron.Entries()) и статусом Paused — для
+// GET /api/cron/entries.
+func (cm *CronManager) Entries() []EntryInfo {
+	idToTask := make(map[cron.EntryID]int, len(cm.entries))
+	for taskID, entryID := range cm.entries {
+		idToTask[entryID] = taskID
+	}
+
+	result := make([]EntryInfo, 0, len(cm.config.Tasks))
+	for _, task := range cm.config.Tasks {
+		if task.ScheduleCron == "" {
+			continue
+		}
+		result = append(result, EntryInfo{TaskID: task.TaskID, Schedule: task.ScheduleCron, Paused: task.Paused})
+	}
+
+	for _, entry := range cm.cron.Entries() {
+		taskID, ok := idToTask[entry.ID]
+		if !ok {
+			continue
+		}
+		for i := range result {
+			if result[i].TaskID == taskID {
+				result[i].Next = entry.Next
+				result[i].Prev = entry.Prev
+				break
+			}
+		}
+	}
+
+	return result
+}
+
 func (cm *CronManager) RemoveTask(taskID int) {
 	if entryID, exists := cm.entries[taskID]; exists {
 		cm.cron.Remove(entryID)
@@ -106,6 +198,12 @@ func (cm *CronManager) updateSystemCron(task config.Task) {
 
 	cronLine := fmt.Sprintf("%s %s --task-id %d",
 		cronExpr, execPath, task.TaskID)
+	if task.Paused {
+		// Комментируем, а не удаляем: строка задачи остаётся в crontab.
+		// Ниже она, как и при Resume, формируется заново из ScheduleCron,
+		// поэтому ручные правки в ней не сохраняются.
+		cronLine = "# " + cronLine
+	}
 
 	// Читаем текущий crontab
 	cmd := exec.Command("crontab", "-l")
@@ -0,0 +1,250 @@
+// Package execution ведёт записи о каждом запуске backup.ExecuteBackup —
+// независимо от того, вызван ли он из HTTP (/api/task/execute), cron или
+// CLI (--task-id). Каждый запуск получает Record со Status="running" и
+// отдельный файл лога под <data>/logs/task-<id>/<timestamp>.log, в который
+// тот же *logger.Logger, что используется в ExecuteBackup, дублирует весь
+// вывод (включая вывод хуков из internal/hooks). По завершении запуска
+// Record закрывается итогом (Status/Message/ArchivePath/S3Path/Bytes), и
+// применяется RecordRetentionPolicy задачи, удаляющая устаревшие записи и
+// их файлы логов — аналогично removeExpiredLog в 1Panel.
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"backup-server-agent/internal/config"
+	"github.com/google/uuid"
+)
+
+const (
+	dataDir     = "/var/lib/backup-server-agent"
+	recordsFile = "records.json"
+	logsDirName = "logs"
+)
+
+// Record — одна запись о выполнении задачи.
+type Record struct {
+	ID          string    `json:"id"`
+	TaskID      int       `json:"task_id"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time,omitempty"`
+	Status      string    `json:"status"` // running|success|failed|skipped
+	Message     string    `json:"message,omitempty"`
+	ArchivePath string    `json:"archive_path,omitempty"`
+	S3Path      string    `json:"s3_path,omitempty"`
+	Bytes       int64     `json:"bytes,omitempty"`
+	LogPath     string    `json:"log_path,omitempty"`
+}
+
+// Run — открытое исполнение задачи: Record плюс файл лога, в который
+// пишется весь вывод через Writer().
+type Run struct {
+	Record Record
+	file   *os.File
+}
+
+// Policy — параметры прунинга записей в разобранном виде.
+type Policy struct {
+	KeepRecords int
+	KeepDays    int
+}
+
+// PolicyFromTask строит Policy из Task.RecordRetention.
+func PolicyFromTask(t config.Task) Policy {
+	return Policy{
+		KeepRecords: t.RecordRetention.KeepRecords,
+		KeepDays:    t.RecordRetention.KeepDays,
+	}
+}
+
+func recordsPath() string {
+	return filepath.Join(dataDir, recordsFile)
+}
+
+func logDir(taskID int) string {
+	return filepath.Join(dataDir, logsDirName, fmt.Sprintf("task-%d", taskID))
+}
+
+// Begin открывает запись о выполнении задачи taskID: создаёт
+// <data>/logs/task-<id>/<timestamp>.log и сохраняет Record со
+// Status="running".
+func Begin(taskID int) (*Run, error) {
+	dir := logDir(taskID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log dir: %v", err)
+	}
+
+	now := time.Now()
+	logPath := filepath.Join(dir, now.Format("20060102_150405")+".log")
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %v", err)
+	}
+
+	rec := Record{
+		ID:        uuid.NewString(),
+		TaskID:    taskID,
+		StartTime: now,
+		Status:    "running",
+		LogPath:   logPath,
+	}
+	if err := appendRecord(rec); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Run{Record: rec, file: f}, nil
+}
+
+// Writer возвращает io.Writer файла лога этого запуска — передаётся в
+// logger.New как дополнительный writer, чтобы весь вывод логгера попадал в
+// лог выполнения.
+func (r *Run) Writer() io.Writer {
+	return r.file
+}
+
+// Finish закрывает файл лога, сохраняет итог выполнения и применяет
+// RecordRetentionPolicy задачи, удаляя истёкшие записи и их файлы логов.
+func (r *Run) Finish(policy Policy, status, message, archivePath, s3Path string, bytes int64) {
+	r.file.Close()
+
+	r.Record.EndTime = time.Now()
+	r.Record.Status = status
+	r.Record.Message = message
+	r.Record.ArchivePath = archivePath
+	r.Record.S3Path = s3Path
+	r.Record.Bytes = bytes
+
+	if err := updateRecord(r.Record); err != nil {
+		return
+	}
+	prune(r.Record.TaskID, policy)
+}
+
+func loadRecords() ([]Record, error) {
+	records := []Record{}
+	data, err := os.ReadFile(recordsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func saveRecords(records []Record) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recordsPath(), data, 0644)
+}
+
+func appendRecord(rec Record) error {
+	records, err := loadRecords()
+	if err != nil {
+		return err
+	}
+	records = append(records, rec)
+	return saveRecords(records)
+}
+
+func updateRecord(rec Record) error {
+	records, err := loadRecords()
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].ID == rec.ID {
+			records[i] = rec
+			break
+		}
+	}
+	return saveRecords(records)
+}
+
+// GetRecordsByTask возвращает записи выполнения для задачи taskID,
+// отсортированные от новых к старым.
+func GetRecordsByTask(taskID int) ([]Record, error) {
+	records, err := loadRecords()
+	if err != nil {
+		return nil, err
+	}
+	var matched []Record
+	for _, r := range records {
+		if r.TaskID == taskID {
+			matched = append(matched, r)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].StartTime.After(matched[j].StartTime) })
+	return matched, nil
+}
+
+// GetRecord ищет запись по ID.
+func GetRecord(id string) (*Record, error) {
+	records, err := loadRecords()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// prune удаляет записи задачи taskID, не укладывающиеся в policy —
+// превышающие KeepRecords самых новых или старше KeepDays дней, — вместе с
+// их файлами логов. Ноль в обоих полях policy отключает прунинг.
+func prune(taskID int, policy Policy) {
+	if policy.KeepRecords <= 0 && policy.KeepDays <= 0 {
+		return
+	}
+
+	records, err := loadRecords()
+	if err != nil {
+		return
+	}
+
+	var taskRecords, other []Record
+	for _, r := range records {
+		if r.TaskID == taskID {
+			taskRecords = append(taskRecords, r)
+		} else {
+			other = append(other, r)
+		}
+	}
+	sort.Slice(taskRecords, func(i, j int) bool { return taskRecords[i].StartTime.After(taskRecords[j].StartTime) })
+
+	now := time.Now()
+	var kept []Record
+	for i, r := range taskRecords {
+		expired := policy.KeepRecords > 0 && i >= policy.KeepRecords
+		if !expired && policy.KeepDays > 0 {
+			expired = now.Sub(r.StartTime) > time.Duration(policy.KeepDays)*24*time.Hour
+		}
+		if expired {
+			if r.LogPath != "" {
+				os.Remove(r.LogPath)
+			}
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	saveRecords(append(other, kept...))
+}
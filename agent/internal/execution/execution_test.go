@@ -0,0 +1,174 @@
+package execution
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testTaskID — заведомо не пересекается с реальными ID задач в records.json.
+const testTaskID = 999001
+
+func cleanupTask(t *testing.T, taskID int) {
+	t.Helper()
+	t.Cleanup(func() {
+		records, err := loadRecords()
+		if err != nil {
+			return
+		}
+		var kept []Record
+		for _, r := range records {
+			if r.TaskID == taskID {
+				if r.LogPath != "" {
+					os.Remove(r.LogPath)
+				}
+				continue
+			}
+			kept = append(kept, r)
+		}
+		saveRecords(kept)
+		os.RemoveAll(logDir(taskID))
+	})
+}
+
+// TestBeginPerRunLogFiles проверяет, что каждый запуск (Begin) получает
+// собственный файл лога — ротация по времени запуска, а не один общий файл
+// на задачу.
+func TestBeginPerRunLogFiles(t *testing.T) {
+	cleanupTask(t, testTaskID)
+
+	run1, err := Begin(testTaskID)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	run1.Finish(Policy{}, "success", "first run", "", "", 0)
+
+	// Формат таймстемпа в имени файла — секундной точности, так что ждём,
+	// чтобы второй запуск точно получил другое имя файла.
+	time.Sleep(1100 * time.Millisecond)
+
+	run2, err := Begin(testTaskID)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	run2.Finish(Policy{}, "success", "second run", "", "", 0)
+
+	if run1.Record.LogPath == run2.Record.LogPath {
+		t.Fatalf("expected distinct log files per run, got %q for both", run1.Record.LogPath)
+	}
+	for _, path := range []string{run1.Record.LogPath, run2.Record.LogPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected log file %q to exist: %v", path, err)
+		}
+	}
+}
+
+// TestWriterLargeOutput проверяет, что Writer() полностью передаёт большой
+// объём вывода в файл лога записи, без потерь и усечения.
+func TestWriterLargeOutput(t *testing.T) {
+	taskID := testTaskID + 1
+	cleanupTask(t, taskID)
+
+	run, err := Begin(taskID)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+
+	want := bytes.Repeat([]byte("0123456789abcdef"), 256*1024) // 4 MiB
+	if _, err := run.Writer().Write(want); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	run.Finish(Policy{}, "success", "large log", "", "", 0)
+
+	got, err := os.ReadFile(run.Record.LogPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("log file content mismatch: got %d bytes, want %d bytes", len(got), len(want))
+	}
+}
+
+// TestPruneByKeepRecords проверяет, что prune оставляет только KeepRecords
+// самых новых записей задачи, удаляя более старые вместе с их файлами лога.
+func TestPruneByKeepRecords(t *testing.T) {
+	taskID := testTaskID + 2
+	cleanupTask(t, taskID)
+
+	dir := logDir(taskID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		logPath := filepath.Join(dir, fmt.Sprintf("run-%d.log", i))
+		if err := os.WriteFile(logPath, []byte("log"), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		rec := Record{
+			ID:        fmt.Sprintf("rec-%d", i),
+			TaskID:    taskID,
+			StartTime: now.Add(time.Duration(i) * time.Minute),
+			Status:    "success",
+			LogPath:   logPath,
+		}
+		if err := appendRecord(rec); err != nil {
+			t.Fatalf("appendRecord: %v", err)
+		}
+	}
+
+	prune(taskID, Policy{KeepRecords: 2})
+
+	records, err := GetRecordsByTask(taskID)
+	if err != nil {
+		t.Fatalf("GetRecordsByTask: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records to survive pruning, got %d", len(records))
+	}
+	// Должны остаться только два самых новых запуска (run-3, run-4).
+	for _, r := range records {
+		if r.ID != "rec-3" && r.ID != "rec-4" {
+			t.Errorf("unexpected surviving record %q", r.ID)
+		}
+		if _, err := os.Stat(r.LogPath); err != nil {
+			t.Errorf("expected surviving log file %q to exist: %v", r.LogPath, err)
+		}
+	}
+	// Файлы лога удалённых записей должны быть удалены.
+	for i := 0; i < 3; i++ {
+		logPath := filepath.Join(dir, fmt.Sprintf("run-%d.log", i))
+		if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+			t.Errorf("expected pruned log file %q to be removed", logPath)
+		}
+	}
+}
+
+// TestPruneByKeepDays проверяет прунинг по возрасту записи.
+func TestPruneByKeepDays(t *testing.T) {
+	taskID := testTaskID + 3
+	cleanupTask(t, taskID)
+
+	oldRec := Record{ID: "old", TaskID: taskID, StartTime: time.Now().Add(-48 * time.Hour), Status: "success"}
+	freshRec := Record{ID: "fresh", TaskID: taskID, StartTime: time.Now(), Status: "success"}
+	if err := appendRecord(oldRec); err != nil {
+		t.Fatalf("appendRecord: %v", err)
+	}
+	if err := appendRecord(freshRec); err != nil {
+		t.Fatalf("appendRecord: %v", err)
+	}
+
+	prune(taskID, Policy{KeepDays: 1})
+
+	records, err := GetRecordsByTask(taskID)
+	if err != nil {
+		t.Fatalf("GetRecordsByTask: %v", err)
+	}
+	if len(records) != 1 || records[0].ID != "fresh" {
+		t.Fatalf("expected only the fresh record to survive, got %+v", records)
+	}
+}
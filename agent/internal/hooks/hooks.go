@@ -0,0 +1,285 @@
+// Package hooks выполняет Task.PreHooks/PostHooks — произвольные команды
+// (exec), контейнерные команды (docker_exec), остановку/перезапуск
+// контейнеров по label-селектору (docker_stop) и HTTP-запросы (http),
+// выполняемые до и после бэкапа. Весь вывод хуков идёт через
+// internal/logger с атрибутом "hook".
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"backup-server-agent/internal/config"
+	"backup-server-agent/internal/logger"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Result — захваченный вывод одного выполненного хука, сохраняется в
+// BackupRecord и отдаётся через records API для отладки.
+type Result struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// State хранит ID контейнеров, остановленных хуками docker_stop в
+// PreHooks, чтобы соответствующий хук в PostHooks мог перезапустить именно
+// их, а не все контейнеры, подходящие под селектор на тот момент, а также
+// накопленный вывод всех выполненных хуков (Pre и Post) для записи бэкапа.
+type State struct {
+	stopped map[string][]string // селектор -> ID остановленных контейнеров
+	Results []Result
+}
+
+// RunPre выполняет hooks по порядку. Если хук завершается с ошибкой и
+// ContinueOnError=false, выполнение прерывается и ошибка возвращается.
+func RunPre(ctx context.Context, taskHooks []config.Hook, log *logger.Logger) (*State, error) {
+	state := &State{stopped: map[string][]string{}}
+	for _, h := range taskHooks {
+		if err := runOne(ctx, h, state, true, log); err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// RunPost выполняет hooks в обратном порядке (как defer), используя state,
+// собранный в RunPre, чтобы docker_stop-хуки перезапустили ранее
+// остановленные ими контейнеры.
+func RunPost(ctx context.Context, taskHooks []config.Hook, state *State, log *logger.Logger) error {
+	if state == nil {
+		state = &State{stopped: map[string][]string{}}
+	}
+	for i := len(taskHooks) - 1; i >= 0; i-- {
+		if err := runOne(ctx, taskHooks[i], state, false, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runOne(ctx context.Context, h config.Hook, state *State, pre bool, log *logger.Logger) error {
+	hlog := log.With("hook", h.Type)
+
+	timeout := defaultTimeout
+	if h.Timeout != "" {
+		if d, err := time.ParseDuration(h.Timeout); err == nil {
+			timeout = d
+		} else {
+			hlog.Warnf("Invalid hook timeout %q, using default: %v", h.Timeout, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var output string
+	var err error
+	switch h.Type {
+	case "exec":
+		output, err = runExec(ctx, h, hlog)
+	case "docker_stop":
+		if pre {
+			output, err = dockerStop(ctx, h, state, hlog)
+		} else {
+			output, err = dockerStart(ctx, h, state, hlog)
+		}
+	case "docker_exec":
+		output, err = dockerExec(ctx, h, hlog)
+	case "http":
+		output, err = runHTTP(ctx, h, hlog)
+	default:
+		err = fmt.Errorf("unknown hook type %q", h.Type)
+	}
+
+	result := Result{Type: h.Type, Command: h.Command, Output: output}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	state.Results = append(state.Results, result)
+
+	if err != nil {
+		hlog.Warnf("Hook failed: %v", err)
+		if !h.ContinueOnError {
+			return err
+		}
+		return nil
+	}
+	return nil
+}
+
+func runExec(ctx context.Context, h config.Hook, log *logger.Logger) (string, error) {
+	cmd := exec.CommandContext(ctx, h.Command, h.Args...)
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		log.Infof("%s", output)
+	}
+	if err != nil {
+		return string(output), fmt.Errorf("exec %s: %v", h.Command, err)
+	}
+	return string(output), nil
+}
+
+func runHTTP(ctx context.Context, h config.Hook, log *logger.Logger) (string, error) {
+	method := http.MethodGet
+	if len(h.Args) > 0 {
+		method = h.Args[0]
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.Command, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	status := resp.Status
+	log.Infof("HTTP hook %s %s -> %s", method, h.Command, status)
+	if resp.StatusCode >= 300 {
+		return status, fmt.Errorf("http hook %s returned %s", h.Command, status)
+	}
+	return status, nil
+}
+
+func dockerClient() (*client.Client, error) {
+	return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+}
+
+// matchingContainers возвращает контейнеры (включая остановленные),
+// подходящие под selector: либо label-селектор вида "key=value", либо
+// список имён контейнеров через запятую (docker_stop.StopContainers).
+func matchingContainers(ctx context.Context, cli *client.Client, selector string) ([]types.Container, error) {
+	args := filters.NewArgs()
+	if strings.Contains(selector, "=") {
+		args.Add("label", selector)
+	} else {
+		for _, name := range strings.Split(selector, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				args.Add("name", name)
+			}
+		}
+	}
+	return cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+}
+
+func dockerStop(ctx context.Context, h config.Hook, state *State, log *logger.Logger) (string, error) {
+	cli, err := dockerClient()
+	if err != nil {
+		return "", fmt.Errorf("docker client: %v", err)
+	}
+	defer cli.Close()
+
+	containers, err := matchingContainers(ctx, cli, h.Command)
+	if err != nil {
+		return "", fmt.Errorf("listing containers matching %s: %v", h.Command, err)
+	}
+
+	var stopped []string
+	var names []string
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+		log.Infof("Stopping container %s (selector %s)", c.ID[:12], h.Command)
+		if err := cli.ContainerStop(ctx, c.ID, container.StopOptions{}); err != nil {
+			log.Warnf("Failed to stop container %s: %v", c.ID[:12], err)
+			continue
+		}
+		stopped = append(stopped, c.ID)
+		names = append(names, c.ID[:12])
+	}
+	state.stopped[h.Command] = stopped
+	return fmt.Sprintf("stopped: %s", strings.Join(names, ", ")), nil
+}
+
+func dockerStart(ctx context.Context, h config.Hook, state *State, log *logger.Logger) (string, error) {
+	ids := state.stopped[h.Command]
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	cli, err := dockerClient()
+	if err != nil {
+		return "", fmt.Errorf("docker client: %v", err)
+	}
+	defer cli.Close()
+
+	var names []string
+	for _, id := range ids {
+		log.Infof("Starting container %s (selector %s)", id[:12], h.Command)
+		if err := cli.ContainerStart(ctx, id, types.ContainerStartOptions{}); err != nil {
+			log.Warnf("Failed to start container %s: %v", id[:12], err)
+			continue
+		}
+		names = append(names, id[:12])
+	}
+	return fmt.Sprintf("started: %s", strings.Join(names, ", ")), nil
+}
+
+// dockerExec выполняет h.Args внутри каждого контейнера, подходящего под
+// селектор h.Command, и возвращает объединённый вывод всех контейнеров.
+func dockerExec(ctx context.Context, h config.Hook, log *logger.Logger) (string, error) {
+	cli, err := dockerClient()
+	if err != nil {
+		return "", fmt.Errorf("docker client: %v", err)
+	}
+	defer cli.Close()
+
+	containers, err := matchingContainers(ctx, cli, h.Command)
+	if err != nil {
+		return "", fmt.Errorf("listing containers matching %s: %v", h.Command, err)
+	}
+
+	var combined strings.Builder
+	for _, c := range containers {
+		execCfg := types.ExecConfig{
+			Cmd:          h.Args,
+			AttachStdout: true,
+			AttachStderr: true,
+		}
+		execID, err := cli.ContainerExecCreate(ctx, c.ID, execCfg)
+		if err != nil {
+			log.Warnf("Failed to create exec in %s: %v", c.ID[:12], err)
+			continue
+		}
+
+		attach, err := cli.ContainerExecAttach(ctx, execID.ID, types.ExecStartCheck{})
+		if err != nil {
+			log.Warnf("Failed to attach exec in %s: %v", c.ID[:12], err)
+			continue
+		}
+		output, _ := readAll(attach)
+		attach.Close()
+		if len(output) > 0 {
+			log.Infof("[%s] %s", c.ID[:12], output)
+		}
+		fmt.Fprintf(&combined, "[%s] %s\n", c.ID[:12], output)
+	}
+	return combined.String(), nil
+}
+
+func readAll(attach types.HijackedResponse) ([]byte, error) {
+	buf := make([]byte, 0, 4096)
+	chunk := make([]byte, 4096)
+	for {
+		n, err := attach.Reader.Read(chunk)
+		if n > 0 {
+			buf = append(buf, chunk[:n]...)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return buf, nil
+}
@@ -0,0 +1,96 @@
+// Package lock защищает от перекрывающихся запусков одной и той же задачи
+// (in-process cron-тик, системный crontab `--task-id` и HTTP
+// /api/task/execute могут сработать одновременно) и ограничивает число
+// одновременных тяжёлых операций (архивирование/аплоад) во всём агенте.
+// Блокировки файловые (github.com/gofrs/flock), поэтому работают и между
+// процессами — что важно, так как `--task-id` запускается отдельным
+// процессом из системного cron.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+const dataDir = "/var/lib/backup-server-agent"
+
+func locksDir() (string, error) {
+	dir := filepath.Join(dataDir, "locks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// TaskLock возвращает файловую блокировку задачи taskID —
+// <data>/locks/task-<id>.lock.
+func TaskLock(taskID int) (*flock.Flock, error) {
+	dir, err := locksDir()
+	if err != nil {
+		return nil, err
+	}
+	return flock.New(filepath.Join(dir, fmt.Sprintf("task-%d.lock", taskID))), nil
+}
+
+// Acquire пытается захватить l. Если skipIfRunning — сразу возвращает
+// acquired=false, если блокировка уже занята. Иначе ждёт её освобождения до
+// maxWait и возвращает acquired=false, если так и не дождался (maxWait<=0 —
+// ждать, пока не получится).
+func Acquire(l *flock.Flock, skipIfRunning bool, maxWait time.Duration) (bool, error) {
+	if skipIfRunning {
+		return l.TryLock()
+	}
+
+	ctx := context.Background()
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+	locked, err := l.TryLockContext(ctx, 200*time.Millisecond)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false, nil
+	}
+	return locked, err
+}
+
+func globalSlot(n int) (*flock.Flock, error) {
+	dir, err := locksDir()
+	if err != nil {
+		return nil, err
+	}
+	return flock.New(filepath.Join(dir, fmt.Sprintf("global-slot-%d.lock", n))), nil
+}
+
+// AcquireGlobalSlot захватывает один из maxConcurrent общих слотов,
+// блокируясь до освобождения, — вместе слоты работают как cross-process
+// семафор, ограничивающий число одновременных тяжёлых операций. Возвращает
+// nil без ожидания, если maxConcurrent<=0 (ограничение отключено).
+func AcquireGlobalSlot(maxConcurrent int) (*flock.Flock, error) {
+	if maxConcurrent <= 0 {
+		return nil, nil
+	}
+
+	for {
+		for i := 0; i < maxConcurrent; i++ {
+			l, err := globalSlot(i)
+			if err != nil {
+				return nil, err
+			}
+			locked, err := l.TryLock()
+			if err != nil {
+				return nil, err
+			}
+			if locked {
+				return l, nil
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
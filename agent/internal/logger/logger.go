@@ -1,55 +1,109 @@
+// Package logger оборачивает log/slog, добавляя структурированный
+// текстовый/JSON вывод, вывод в ротируемый файл и привязку атрибутов
+// (например, task_id, backup_id) через With, сохраняя привычный
+// Info/Warn/Error API, чтобы не трогать вызывающий код.
 package logger
 
 import (
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+
+	"backup-server-agent/internal/config"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type Logger struct {
-	*log.Logger
+	slog *slog.Logger
+}
+
+// New создаёт логгер по cfg.LogFormat (text|json) и cfg.LogLevel
+// (debug|info|warn|error). Если указан cfg.LogFile, вывод дублируется в
+// ротируемый файл через lumberjack. extraWriters дублируют вывод ещё и в
+// них — используется internal/execution, чтобы завести отдельный лог на
+// каждый запуск задачи.
+func New(cfg *config.Config, extraWriters ...io.Writer) *Logger {
+	writers := []io.Writer{os.Stdout}
+	if cfg != nil && cfg.LogFile != "" {
+		writers = append(writers, &lumberjack.Logger{
+			Filename:   cfg.LogFile,
+			MaxSize:    100, // MB
+			MaxBackups: 5,
+			MaxAge:     28, // days
+		})
+	}
+	writers = append(writers, extraWriters...)
+	writer := io.MultiWriter(writers...)
+
+	opts := &slog.HandlerOptions{Level: parseLevel(cfgLevel(cfg))}
+
+	var handler slog.Handler
+	if cfg != nil && cfg.LogFormat == "json" {
+		handler = slog.NewJSONHandler(writer, opts)
+	} else {
+		handler = slog.NewTextHandler(writer, opts)
+	}
+
+	return &Logger{slog: slog.New(handler)}
+}
+
+func cfgLevel(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.LogLevel
 }
 
-func New() *Logger {
-	return &Logger{
-		Logger: log.New(os.Stdout, "[AGENT] ", log.LstdFlags|log.Lshortfile),
+func parseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
 	}
 }
 
+// With возвращает дочерний логгер с привязанными атрибутами, проставленными
+// на каждой последующей записи — удобно для сквозного task_id/backup_id.
+func (l *Logger) With(attrs ...any) *Logger {
+	return &Logger{slog: l.slog.With(attrs...)}
+}
+
 func (l *Logger) Info(v ...interface{}) {
-	l.Logger.Println(append([]interface{}{"INFO:"}, v...)...)
+	l.slog.Info(fmt.Sprint(v...))
 }
 
 func (l *Logger) Infof(format string, v ...interface{}) {
-	l.Logger.Printf("INFO: "+format, v...)
+	l.slog.Info(fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Warn(v ...interface{}) {
-	l.Logger.Println(append([]interface{}{"WARN:"}, v...)...)
+	l.slog.Warn(fmt.Sprint(v...))
 }
 
 func (l *Logger) Warnf(format string, v ...interface{}) {
-	l.Logger.Printf("WARN: "+format, v...)
+	l.slog.Warn(fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Error(v ...interface{}) {
-	l.Logger.Println(append([]interface{}{"ERROR:"}, v...)...)
+	l.slog.Error(fmt.Sprint(v...))
 }
 
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	l.Logger.Printf("ERROR: "+format, v...)
+	l.slog.Error(fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Fatal(v ...interface{}) {
-	l.Logger.Fatal(append([]interface{}{"FATAL:"}, v...)...)
+	l.slog.Error(fmt.Sprint(v...))
+	os.Exit(1)
 }
 
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	l.Logger.Fatalf("FATAL: "+format, v...)
+	l.slog.Error(fmt.Sprintf(format, v...))
+	os.Exit(1)
 }
-
-
-
-
-
-
-
@@ -0,0 +1,178 @@
+// Package notify рассылает уведомления об итогах бэкапа через shoutrrr
+// (Telegram, Slack, Discord, email, Gotify и т.д.), используя шаблоны
+// text/template из config.Config.Notifications.
+package notify
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"backup-server-agent/internal/config"
+	"backup-server-agent/internal/logger"
+	"github.com/containrrr/shoutrrr"
+)
+
+// defaultTrendCount — сколько прошлых бэкапов передавать шаблону, если
+// Config.Notifications.TrendCount не задан.
+const defaultTrendCount = 5
+
+//go:embed templates/success.tmpl
+var defaultSuccessTemplate string
+
+//go:embed templates/failure.tmpl
+var defaultFailureTemplate string
+
+// Stats — срез использования диска до и после выполнения бэкапа,
+// полученный через monitor.GetFilesystemInfo.
+type Stats struct {
+	PreFreeGB   float64
+	PreTotalGB  float64
+	PostFreeGB  float64
+	PostTotalGB float64
+}
+
+// Context — данные, доступные шаблонам уведомлений.
+type Context struct {
+	Task            config.Task
+	StorageBackend  string
+	ArchivePath     string
+	ArchiveSize     int64
+	FilesCount      int
+	S3Path          string
+	Error           string
+	Success         bool
+	StartTime       time.Time
+	EndTime         time.Time
+	Duration        time.Duration
+	Stats           Stats
+	// PreviousRecords — последние N бэкапов этой же задачи (см. TrendCount),
+	// для шаблонов, показывающих тренд размера/статуса во времени.
+	PreviousRecords []RecordSummary
+}
+
+// RecordSummary — сокращённая версия backup.BackupRecord для шаблонов
+// уведомлений. Отдельный тип вместо backup.BackupRecord, т.к.
+// internal/backup уже импортирует internal/notify.
+type RecordSummary struct {
+	BackupDate    time.Time
+	ArchiveSizeMB float64
+	Status        string
+}
+
+// Send рендерит и отправляет уведомление об итогах бэкапа согласно
+// cfg.Notifications. Возвращает отрендеренное сообщение, чтобы вызывающий
+// код мог использовать его сам (например, вернуть в HTTP-ответе). Если
+// URLs не заданы или Trigger исключает этот исход, шаблон не рендерится и
+// возвращается пустая строка.
+func Send(cfg config.Config, ctx Context, log *logger.Logger) (string, error) {
+	notifCfg := cfg.Notifications
+	if len(notifCfg.URLs) == 0 {
+		return "", nil
+	}
+
+	if !shouldNotify(notifCfg.Trigger, ctx.Success) {
+		return "", nil
+	}
+
+	tmplText := notifCfg.SuccessTemplate
+	overridePath := ctx.Task.NotifySuccessTemplatePath
+	if !ctx.Success {
+		tmplText = notifCfg.FailureTemplate
+		overridePath = ctx.Task.NotifyFailureTemplatePath
+	}
+	if overridePath != "" {
+		if data, err := os.ReadFile(overridePath); err == nil {
+			tmplText = string(data)
+		} else {
+			log.Warnf("Failed to read template override %s, falling back: %v", overridePath, err)
+		}
+	}
+	if tmplText == "" {
+		if ctx.Success {
+			tmplText = defaultSuccessTemplate
+		} else {
+			tmplText = defaultFailureTemplate
+		}
+	}
+
+	message, err := render(tmplText, ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to render notification template: %v", err)
+	}
+
+	sender, err := shoutrrr.CreateSender(notifCfg.URLs...)
+	if err != nil {
+		return message, fmt.Errorf("failed to create notification sender: %v", err)
+	}
+
+	for _, sendErr := range sender.Send(message, nil) {
+		if sendErr != nil {
+			log.Warnf("Failed to send notification: %v", sendErr)
+		}
+	}
+
+	return message, nil
+}
+
+// TrendCount возвращает сколько прошлых записей нужно подмешать в
+// Context.PreviousRecords согласно notifCfg.TrendCount (или значение по
+// умолчанию, если не задано).
+func TrendCount(notifCfg config.NotificationsConfig) int {
+	if notifCfg.TrendCount > 0 {
+		return notifCfg.TrendCount
+	}
+	return defaultTrendCount
+}
+
+func shouldNotify(trigger string, success bool) bool {
+	switch trigger {
+	case "on_failure":
+		return !success
+	case "on_success":
+		return success
+	default:
+		// "always" и пустое значение по умолчанию
+		return true
+	}
+}
+
+func render(tmplText string, ctx Context) (string, error) {
+	funcs := template.FuncMap{
+		"formatBytes":    formatBytes,
+		"formatDuration": formatDuration,
+		"now":            time.Now,
+	}
+
+	tmpl, err := template.New("notification").Funcs(funcs).Parse(tmplText)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
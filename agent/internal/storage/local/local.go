@@ -0,0 +1,118 @@
+// Package local реализует storage.Backend простым копированием в другую
+// директорию на том же хосте.
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"backup-server-agent/internal/storage/object"
+)
+
+// Config — типизированные настройки local-бэкенда, декодируемые из
+// Task.StorageConfig.
+type Config struct {
+	Path string `json:"path"`
+}
+
+type Backend struct {
+	path string
+}
+
+func New(cfg Config) (*Backend, error) {
+	if err := os.MkdirAll(cfg.Path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+	return &Backend{path: cfg.Path}, nil
+}
+
+func (b *Backend) Name() string {
+	return "local"
+}
+
+func (b *Backend) Upload(ctx context.Context, localPath, remoteKey string) (string, error) {
+	if remoteKey == "" {
+		remoteKey = filepath.Base(localPath)
+	}
+
+	destPath := filepath.Join(b.path, remoteKey)
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %v", err)
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(destPath)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return "", fmt.Errorf("failed to copy file: %v", err)
+	}
+
+	return destPath, nil
+}
+
+func (b *Backend) UploadStream(ctx context.Context, remoteKey string, r io.Reader, size int64) (string, error) {
+	destPath := filepath.Join(b.path, remoteKey)
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %v", err)
+	}
+
+	dst, err := os.Create(destPath)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, r); err != nil {
+		return "", fmt.Errorf("failed to stream file: %v", err)
+	}
+
+	return destPath, nil
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]object.Object, error) {
+	dir := filepath.Join(b.path, prefix)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objects []object.Object
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object.Object{
+			Key:     filepath.Join(prefix, entry.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return os.Remove(filepath.Join(b.path, key))
+}
+
+func (b *Backend) Close() error {
+	return nil
+}
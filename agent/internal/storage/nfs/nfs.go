@@ -0,0 +1,142 @@
+// Package nfs реализует storage.Backend поверх уже смонтированного NFS-пути,
+// используя atomic rename, чтобы не оставлять частично записанные файлы.
+package nfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"backup-server-agent/internal/storage/object"
+)
+
+// Config — типизированные настройки NFS-бэкенда, декодируемые из
+// Task.StorageConfig. MountPath должен быть уже смонтированной точкой NFS.
+type Config struct {
+	MountPath string `json:"mount_path"`
+}
+
+type Backend struct {
+	mountPath string
+}
+
+func New(cfg Config) (*Backend, error) {
+	if err := os.MkdirAll(cfg.MountPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mount path: %v", err)
+	}
+	return &Backend{mountPath: cfg.MountPath}, nil
+}
+
+func (b *Backend) Name() string {
+	return "nfs"
+}
+
+func (b *Backend) Upload(ctx context.Context, localPath, remoteKey string) (string, error) {
+	if remoteKey == "" {
+		remoteKey = filepath.Base(localPath)
+	}
+
+	destPath := filepath.Join(b.mountPath, remoteKey)
+	tmpPath := destPath + ".tmp"
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %v", err)
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to copy file: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to finalize file: %v", err)
+	}
+
+	return destPath, nil
+}
+
+func (b *Backend) UploadStream(ctx context.Context, remoteKey string, r io.Reader, size int64) (string, error) {
+	destPath := filepath.Join(b.mountPath, remoteKey)
+	tmpPath := destPath + ".tmp"
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %v", err)
+	}
+
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(dst, r); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to stream file: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to finalize file: %v", err)
+	}
+
+	return destPath, nil
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]object.Object, error) {
+	dir := filepath.Join(b.mountPath, prefix)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objects []object.Object
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object.Object{
+			Key:     filepath.Join(prefix, entry.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return os.Remove(filepath.Join(b.mountPath, key))
+}
+
+func (b *Backend) Close() error {
+	return nil
+}
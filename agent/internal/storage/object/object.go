@@ -0,0 +1,12 @@
+// Package object holds the value type shared between the storage package
+// and its backend sub-packages, kept separate to avoid an import cycle.
+package object
+
+import "time"
+
+// Object описывает запись в хранилище, возвращаемую Backend.List.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
@@ -0,0 +1,108 @@
+// Package s3 реализует storage.Backend поверх MinIO/S3-совместимого API.
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"backup-server-agent/internal/storage/object"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// streamPartSize — размер части multipart-загрузки, используемый при
+// потоковой отдаче объекта неизвестного размера.
+const streamPartSize = 64 * 1024 * 1024
+
+// Config — типизированные настройки S3-бэкенда, декодируемые из
+// Task.StorageConfig.
+type Config struct {
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Bucket    string `json:"bucket"`
+	Region    string `json:"region"`
+}
+
+type Backend struct {
+	client *minio.Client
+	bucket string
+}
+
+func New(cfg Config) (*Backend, error) {
+	endpoint := strings.TrimPrefix(strings.TrimPrefix(cfg.Endpoint, "http://"), "https://")
+	useSSL := strings.HasPrefix(cfg.Endpoint, "https://")
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: useSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %v", err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket: %v", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket: %v", err)
+		}
+	}
+
+	return &Backend{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (b *Backend) Name() string {
+	return "s3"
+}
+
+func (b *Backend) Upload(ctx context.Context, localPath, remoteKey string) (string, error) {
+	if remoteKey == "" {
+		remoteKey = filepath.Base(localPath)
+	}
+	if _, err := b.client.FPutObject(ctx, b.bucket, remoteKey, localPath, minio.PutObjectOptions{}); err != nil {
+		return "", fmt.Errorf("failed to upload file: %v", err)
+	}
+	return fmt.Sprintf("s3://%s/%s", b.bucket, remoteKey), nil
+}
+
+func (b *Backend) UploadStream(ctx context.Context, remoteKey string, r io.Reader, size int64) (string, error) {
+	opts := minio.PutObjectOptions{}
+	if size < 0 {
+		opts.PartSize = streamPartSize
+	}
+	if _, err := b.client.PutObject(ctx, b.bucket, remoteKey, r, size, opts); err != nil {
+		return "", fmt.Errorf("failed to upload stream: %v", err)
+	}
+	return fmt.Sprintf("s3://%s/%s", b.bucket, remoteKey), nil
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]object.Object, error) {
+	var objects []object.Object
+	for o := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if o.Err != nil {
+			return nil, o.Err
+		}
+		objects = append(objects, object.Object{
+			Key:     o.Key,
+			Size:    o.Size,
+			ModTime: o.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return b.client.RemoveObject(ctx, b.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (b *Backend) Close() error {
+	return nil
+}
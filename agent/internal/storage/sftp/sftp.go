@@ -0,0 +1,149 @@
+// Package sftp реализует storage.Backend поверх SFTP с аутентификацией по
+// ключу.
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"backup-server-agent/internal/storage/object"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Config — типизированные настройки SFTP-бэкенда, декодируемые из
+// Task.StorageConfig.
+type Config struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	User       string `json:"user"`
+	KeyFile    string `json:"key_file"`
+	RemotePath string `json:"remote_path"`
+}
+
+type Backend struct {
+	client     *sftp.Client
+	conn       *ssh.Client
+	remotePath string
+}
+
+func New(cfg Config) (*Backend, error) {
+	key, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SFTP key file: %v", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SFTP key file: %v", err)
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", cfg.Host, port), sshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SFTP host: %v", err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create SFTP client: %v", err)
+	}
+
+	return &Backend{client: client, conn: conn, remotePath: cfg.RemotePath}, nil
+}
+
+func (b *Backend) Name() string {
+	return "sftp"
+}
+
+func (b *Backend) Upload(ctx context.Context, localPath, remoteKey string) (string, error) {
+	if remoteKey == "" {
+		remoteKey = filepath.Base(localPath)
+	}
+
+	remotePath := path.Join(b.remotePath, remoteKey)
+	if err := b.client.MkdirAll(path.Dir(remotePath)); err != nil {
+		return "", fmt.Errorf("failed to create remote directory: %v", err)
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := b.client.Create(remotePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create remote file: %v", err)
+	}
+	defer dst.Close()
+
+	if _, err := dst.ReadFrom(src); err != nil {
+		return "", fmt.Errorf("failed to upload file: %v", err)
+	}
+
+	return fmt.Sprintf("sftp://%s@%s", b.conn.Conn.User(), remotePath), nil
+}
+
+func (b *Backend) UploadStream(ctx context.Context, remoteKey string, r io.Reader, size int64) (string, error) {
+	remotePath := path.Join(b.remotePath, remoteKey)
+	if err := b.client.MkdirAll(path.Dir(remotePath)); err != nil {
+		return "", fmt.Errorf("failed to create remote directory: %v", err)
+	}
+
+	dst, err := b.client.Create(remotePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create remote file: %v", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, r); err != nil {
+		return "", fmt.Errorf("failed to upload stream: %v", err)
+	}
+
+	return fmt.Sprintf("sftp://%s@%s", b.conn.Conn.User(), remotePath), nil
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]object.Object, error) {
+	entries, err := b.client.ReadDir(path.Join(b.remotePath, prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []object.Object
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		objects = append(objects, object.Object{
+			Key:     path.Join(prefix, entry.Name()),
+			Size:    entry.Size(),
+			ModTime: entry.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return b.client.Remove(path.Join(b.remotePath, key))
+}
+
+func (b *Backend) Close() error {
+	b.client.Close()
+	return b.conn.Close()
+}
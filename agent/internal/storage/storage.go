@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"backup-server-agent/internal/config"
+	"backup-server-agent/internal/storage/local"
+	"backup-server-agent/internal/storage/nfs"
+	"backup-server-agent/internal/storage/object"
+	"backup-server-agent/internal/storage/s3"
+	"backup-server-agent/internal/storage/sftp"
+	"backup-server-agent/internal/storage/webdav"
+)
+
+// Object описывает запись в хранилище, возвращаемую Backend.List.
+type Object = object.Object
+
+// Backend — единый интерфейс для всех бэкендов хранения бэкапов.
+type Backend interface {
+	Name() string
+	Upload(ctx context.Context, localPath, remoteKey string) (string, error)
+	// UploadStream загружает содержимое r как remoteKey без промежуточного
+	// файла на диске. size может быть -1, если размер неизвестен заранее
+	// (например, при потоковом архивировании).
+	UploadStream(ctx context.Context, remoteKey string, r io.Reader, size int64) (string, error)
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
+
+// New создаёт бэкенд хранилища на основе task.StorageType/task.StorageConfig.
+// Если StorageType не указан, используются устаревшие S3-поля Task как
+// совместимый вариант конфигурации.
+func New(task config.Task) (Backend, error) {
+	storageType := task.StorageType
+	rawConfig := task.StorageConfig
+
+	if storageType == "" {
+		storageType = "s3"
+		rawConfig = s3CompatConfig(task)
+	}
+
+	switch storageType {
+	case "s3":
+		var cfg s3.Config
+		if err := unmarshalConfig(rawConfig, &cfg); err != nil {
+			return nil, err
+		}
+		return s3.New(cfg)
+	case "sftp":
+		var cfg sftp.Config
+		if err := unmarshalConfig(rawConfig, &cfg); err != nil {
+			return nil, err
+		}
+		return sftp.New(cfg)
+	case "webdav":
+		var cfg webdav.Config
+		if err := unmarshalConfig(rawConfig, &cfg); err != nil {
+			return nil, err
+		}
+		return webdav.New(cfg)
+	case "nfs":
+		var cfg nfs.Config
+		if err := unmarshalConfig(rawConfig, &cfg); err != nil {
+			return nil, err
+		}
+		return nfs.New(cfg)
+	case "local":
+		var cfg local.Config
+		if err := unmarshalConfig(rawConfig, &cfg); err != nil {
+			return nil, err
+		}
+		return local.New(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", storageType)
+	}
+}
+
+func unmarshalConfig(raw string, out interface{}) error {
+	if raw == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return fmt.Errorf("failed to parse storage config: %v", err)
+	}
+	return nil
+}
+
+// s3CompatConfig синтезирует JSON StorageConfig для S3 из устаревших полей
+// Task, чтобы существующие задачи без StorageType продолжали работать.
+func s3CompatConfig(task config.Task) string {
+	cfg := s3.Config{
+		Endpoint:  task.S3Endpoint,
+		AccessKey: task.S3AccessKey,
+		SecretKey: task.S3SecretKey,
+		Bucket:    task.S3Bucket,
+		Region:    task.S3Region,
+	}
+	data, _ := json.Marshal(cfg)
+	return string(data)
+}
@@ -0,0 +1,101 @@
+// Package webdav реализует storage.Backend поверх WebDAV.
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"backup-server-agent/internal/storage/object"
+	"github.com/studio-b12/gowebdav"
+)
+
+// Config — типизированные настройки WebDAV-бэкенда, декодируемые из
+// Task.StorageConfig.
+type Config struct {
+	URL        string `json:"url"`
+	User       string `json:"user"`
+	Password   string `json:"password"`
+	RemotePath string `json:"remote_path"`
+}
+
+type Backend struct {
+	client     *gowebdav.Client
+	remotePath string
+}
+
+func New(cfg Config) (*Backend, error) {
+	client := gowebdav.NewClient(cfg.URL, cfg.User, cfg.Password)
+	if err := client.MkdirAll(cfg.RemotePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create remote directory: %v", err)
+	}
+	return &Backend{client: client, remotePath: cfg.RemotePath}, nil
+}
+
+func (b *Backend) Name() string {
+	return "webdav"
+}
+
+func (b *Backend) Upload(ctx context.Context, localPath, remoteKey string) (string, error) {
+	if remoteKey == "" {
+		remoteKey = filepath.Base(localPath)
+	}
+
+	data, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer data.Close()
+
+	remotePath := path.Join(b.remotePath, remoteKey)
+	if err := b.client.MkdirAll(path.Dir(remotePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create remote directory: %v", err)
+	}
+	if err := b.client.WriteStream(remotePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to upload file: %v", err)
+	}
+
+	return fmt.Sprintf("webdav://%s", remotePath), nil
+}
+
+func (b *Backend) UploadStream(ctx context.Context, remoteKey string, r io.Reader, size int64) (string, error) {
+	remotePath := path.Join(b.remotePath, remoteKey)
+	if err := b.client.MkdirAll(path.Dir(remotePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create remote directory: %v", err)
+	}
+	if err := b.client.WriteStream(remotePath, r, 0644); err != nil {
+		return "", fmt.Errorf("failed to upload stream: %v", err)
+	}
+	return fmt.Sprintf("webdav://%s", remotePath), nil
+}
+
+func (b *Backend) List(ctx context.Context, prefix string) ([]object.Object, error) {
+	files, err := b.client.ReadDir(path.Join(b.remotePath, prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []object.Object
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		objects = append(objects, object.Object{
+			Key:     path.Join(prefix, f.Name()),
+			Size:    f.Size(),
+			ModTime: f.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return b.client.Remove(path.Join(b.remotePath, key))
+}
+
+func (b *Backend) Close() error {
+	return nil
+}
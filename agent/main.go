@@ -21,15 +21,16 @@ func main() {
 	taskID := flag.Int("task-id", 0, "Task ID to execute")
 	flag.Parse()
 
-	// Инициализация логгера
-	log := logger.New()
-
 	// Загрузка конфигурации
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
 	}
 
+	// Инициализация логгера по настройкам из конфига
+	log := logger.New(cfg)
+
 	// Если указан task-id, выполняем задачу и выходим
 	if *taskID > 0 {
 		task := cfg.GetTask(*taskID)
@@ -42,7 +43,7 @@ func main() {
 		if serverIP == "" {
 			serverIP = "unknown"
 		}
-		result, err := backup.ExecuteBackup(*task, serverIP, log)
+		result, _, err := backup.ExecuteBackup(*task, serverIP, log, cfg)
 		if err != nil {
 			log.Fatalf("Task execution failed: %v", err)
 		}